@@ -72,85 +72,81 @@ type SeasonsResponse struct {
 
 // ShowsResponse returns a response for a list of TV shows.
 type ShowsResponse struct {
-	XMLName             xml.Name `xml:"MediaContainer"`
-	Text                string   `xml:",chardata"`
-	Size                string   `xml:"size,attr"`
-	AllowSync           string   `xml:"allowSync,attr"`
-	Art                 string   `xml:"art,attr"`
-	Content             string   `xml:"content,attr"`
-	Identifier          string   `xml:"identifier,attr"`
-	LibrarySectionID    string   `xml:"librarySectionID,attr"`
-	LibrarySectionTitle string   `xml:"librarySectionTitle,attr"`
-	LibrarySectionUUID  string   `xml:"librarySectionUUID,attr"`
-	MediaTagPrefix      string   `xml:"mediaTagPrefix,attr"`
-	MediaTagVersion     string   `xml:"mediaTagVersion,attr"`
-	Nocache             string   `xml:"nocache,attr"`
-	Thumb               string   `xml:"thumb,attr"`
-	Title1              string   `xml:"title1,attr"`
-	Title2              string   `xml:"title2,attr"`
-	ViewGroup           string   `xml:"viewGroup,attr"`
-	Directory           []struct {
-		Text                   string `xml:",chardata"`
-		RatingKey              string `xml:"ratingKey,attr"`
-		Key                    string `xml:"key,attr"`
-		GUID                   string `xml:"guid,attr"`
-		Slug                   string `xml:"slug,attr"`
-		Studio                 string `xml:"studio,attr"`
-		Type                   string `xml:"type,attr"`
-		Title                  string `xml:"title,attr"`
-		ContentRating          string `xml:"contentRating,attr"`
-		Summary                string `xml:"summary,attr"`
-		Index                  string `xml:"index,attr"`
-		AudienceRating         string `xml:"audienceRating,attr"`
-		ViewCount              string `xml:"viewCount,attr"`
-		SkipCount              string `xml:"skipCount,attr"`
-		LastViewedAt           string `xml:"lastViewedAt,attr"`
-		Year                   string `xml:"year,attr"`
-		Tagline                string `xml:"tagline,attr"`
-		Thumb                  string `xml:"thumb,attr"`
-		Art                    string `xml:"art,attr"`
-		Theme                  string `xml:"theme,attr"`
-		Duration               string `xml:"duration,attr"`
-		OriginallyAvailableAt  string `xml:"originallyAvailableAt,attr"`
-		LeafCount              string `xml:"leafCount,attr"`
-		ViewedLeafCount        string `xml:"viewedLeafCount,attr"`
-		ChildCount             string `xml:"childCount,attr"`
-		AddedAt                string `xml:"addedAt,attr"`
-		UpdatedAt              string `xml:"updatedAt,attr"`
-		AudienceRatingImage    string `xml:"audienceRatingImage,attr"`
-		HasPremiumPrimaryExtra string `xml:"hasPremiumPrimaryExtra,attr"`
-		PrimaryExtraKey        string `xml:"primaryExtraKey,attr"`
-		HasPremiumExtras       string `xml:"hasPremiumExtras,attr"`
-		SeasonCount            string `xml:"seasonCount,attr"`
-		TitleSort              string `xml:"titleSort,attr"`
-		Rating                 string `xml:"rating,attr"`
-		Banner                 string `xml:"banner,attr"`
-		Image                  []struct {
-			Text string `xml:",chardata"`
-			Alt  string `xml:"alt,attr"`
-			Type string `xml:"type,attr"`
-			URL  string `xml:"url,attr"`
-		} `xml:"Image"`
-		UltraBlurColors struct {
-			Text        string `xml:",chardata"`
-			TopLeft     string `xml:"topLeft,attr"`
-			TopRight    string `xml:"topRight,attr"`
-			BottomRight string `xml:"bottomRight,attr"`
-			BottomLeft  string `xml:"bottomLeft,attr"`
-		} `xml:"UltraBlurColors"`
-		Genre []struct {
-			Text string `xml:",chardata"`
-			Tag  string `xml:"tag,attr"`
-		} `xml:"Genre"`
-		Country []struct {
-			Text string `xml:",chardata"`
-			Tag  string `xml:"tag,attr"`
-		} `xml:"Country"`
-		Role []struct {
-			Text string `xml:",chardata"`
-			Tag  string `xml:"tag,attr"`
-		} `xml:"Role"`
-	} `xml:"Directory"`
+	XMLName             xml.Name        `xml:"MediaContainer"`
+	Text                string          `xml:",chardata"`
+	Size                string          `xml:"size,attr"`
+	AllowSync           string          `xml:"allowSync,attr"`
+	Art                 string          `xml:"art,attr"`
+	Content             string          `xml:"content,attr"`
+	Identifier          string          `xml:"identifier,attr"`
+	LibrarySectionID    string          `xml:"librarySectionID,attr"`
+	LibrarySectionTitle string          `xml:"librarySectionTitle,attr"`
+	LibrarySectionUUID  string          `xml:"librarySectionUUID,attr"`
+	MediaTagPrefix      string          `xml:"mediaTagPrefix,attr"`
+	MediaTagVersion     string          `xml:"mediaTagVersion,attr"`
+	Nocache             string          `xml:"nocache,attr"`
+	Thumb               string          `xml:"thumb,attr"`
+	Title1              string          `xml:"title1,attr"`
+	Title2              string          `xml:"title2,attr"`
+	ViewGroup           string          `xml:"viewGroup,attr"`
+	Directory           []ShowDirectory `xml:"Directory"`
+}
+
+// ShowDirectory is a single show entry in a ShowsResponse.
+type ShowDirectory struct {
+	Text                   string `xml:",chardata"`
+	RatingKey              string `xml:"ratingKey,attr"`
+	Key                    string `xml:"key,attr"`
+	GUID                   string `xml:"guid,attr"`
+	Slug                   string `xml:"slug,attr"`
+	Studio                 string `xml:"studio,attr"`
+	Type                   string `xml:"type,attr"`
+	Title                  string `xml:"title,attr"`
+	ContentRating          string `xml:"contentRating,attr"`
+	Summary                string `xml:"summary,attr"`
+	Index                  string `xml:"index,attr"`
+	AudienceRating         string `xml:"audienceRating,attr"`
+	ViewCount              string `xml:"viewCount,attr"`
+	SkipCount              string `xml:"skipCount,attr"`
+	LastViewedAt           string `xml:"lastViewedAt,attr"`
+	Year                   string `xml:"year,attr"`
+	Tagline                string `xml:"tagline,attr"`
+	Thumb                  string `xml:"thumb,attr"`
+	Art                    string `xml:"art,attr"`
+	Theme                  string `xml:"theme,attr"`
+	Duration               string `xml:"duration,attr"`
+	OriginallyAvailableAt  string `xml:"originallyAvailableAt,attr"`
+	LeafCount              string `xml:"leafCount,attr"`
+	ViewedLeafCount        string `xml:"viewedLeafCount,attr"`
+	ChildCount             string `xml:"childCount,attr"`
+	AddedAt                string `xml:"addedAt,attr"`
+	UpdatedAt              string `xml:"updatedAt,attr"`
+	AudienceRatingImage    string `xml:"audienceRatingImage,attr"`
+	HasPremiumPrimaryExtra string `xml:"hasPremiumPrimaryExtra,attr"`
+	PrimaryExtraKey        string `xml:"primaryExtraKey,attr"`
+	HasPremiumExtras       string `xml:"hasPremiumExtras,attr"`
+	SeasonCount            string `xml:"seasonCount,attr"`
+	TitleSort              string `xml:"titleSort,attr"`
+	Rating                 string `xml:"rating,attr"`
+	Banner                 string `xml:"banner,attr"`
+	Image                  []struct {
+		Text string `xml:",chardata"`
+		Alt  string `xml:"alt,attr"`
+		Type string `xml:"type,attr"`
+		URL  string `xml:"url,attr"`
+	} `xml:"Image"`
+	UltraBlurColors struct {
+		Text        string `xml:",chardata"`
+		TopLeft     string `xml:"topLeft,attr"`
+		TopRight    string `xml:"topRight,attr"`
+		BottomRight string `xml:"bottomRight,attr"`
+		BottomLeft  string `xml:"bottomLeft,attr"`
+	} `xml:"UltraBlurColors"`
+	Genre   []Tag  `xml:"Genre"`
+	Country []Tag  `xml:"Country"`
+	Role    []Tag  `xml:"Role"`
+	Label   []Tag  `xml:"Label"`
+	GUIDs   []GUID `xml:"Guid"`
 }
 
 // LibraryResponse is what we get back when listing the libraries.
@@ -196,6 +192,19 @@ type LibraryResponse struct {
 	} `xml:"Directory"`
 }
 
+// Tag is a single metadata tag such as a genre, label or role.
+type Tag struct {
+	Text string `xml:",chardata" json:"-"`
+	Tag  string `xml:"tag,attr" json:"tag"`
+}
+
+// GUID is an external identifier for a piece of media, such as tvdb://71663.
+type GUID struct {
+	Text string `xml:",chardata" json:"-"`
+	ID   string `xml:"id,attr" json:"id"`
+}
+
+// Video is a single video item, such as an episode, in a response.
 type Video struct {
 	Text                  string `xml:",chardata"`
 	RatingKey             string `xml:"ratingKey,attr"`
@@ -238,6 +247,7 @@ type Video struct {
 	AudienceRatingImage   string `xml:"audienceRatingImage,attr"`
 	ViewCount             string `xml:"viewCount,attr"`
 	SkipCount             string `xml:"skipCount,attr"`
+	AbsoluteIndex         string `xml:"absoluteIndex,attr"`
 	Media                 []struct {
 		Text            string `xml:",chardata"`
 		ID              string `xml:"id,attr"`
@@ -253,7 +263,7 @@ type Video struct {
 		Container       string `xml:"container,attr"`
 		VideoFrameRate  string `xml:"videoFrameRate,attr"`
 		VideoProfile    string `xml:"videoProfile,attr"`
-		Part            struct {
+		Part            []struct {
 			Text         string `xml:",chardata"`
 			ID           string `xml:"id,attr"`
 			Key          string `xml:"key,attr"`
@@ -277,15 +287,11 @@ type Video struct {
 		BottomRight string `xml:"bottomRight,attr"`
 		BottomLeft  string `xml:"bottomLeft,attr"`
 	} `xml:"UltraBlurColors"`
-	Role []struct {
-		Text string `xml:",chardata"`
-		Tag  string `xml:"tag,attr"`
-	} `xml:"Role"`
-	Director []struct {
-		Text string `xml:",chardata"`
-		Tag  string `xml:"tag,attr"`
-	} `xml:"Director"`
-	Writer struct {
+	Role     []Tag `xml:"Role"`
+	Director []Tag `xml:"Director"`
+	Genre    []Tag `xml:"Genre"`
+	Label    []Tag `xml:"Label"`
+	Writer   struct {
 		Text string `xml:",chardata"`
 		Tag  string `xml:"tag,attr"`
 	} `xml:"Writer"`
@@ -12,7 +12,10 @@ var analyzeCmd = &cobra.Command{
 	Use:   "analyze",
 	Short: "Analyze something from the plex server",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 		shows, err := p.Shows.StrictMatch(goflex.ShowTitle(mustGetCmd[string](*cmd, "title")))
 		if err != nil {
 			return err
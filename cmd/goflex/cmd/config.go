@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with goflex config files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return errors.New(cmd.UsageString())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	goflex "github.com/drewstinnett/go-flex"
+	"github.com/spf13/cobra"
+)
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for goflex config files",
+	Long: `Print the JSON Schema for goflex config files.
+
+Editors using the YAML language server can validate and complete configs with
+it by adding a modeline to the top of the config:
+
+  # yaml-language-server: $schema=./goflex.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		b, err := goflex.ConfigSchemaJSON()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd)
+}
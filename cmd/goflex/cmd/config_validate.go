@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	goflex "github.com/drewstinnett/go-flex"
+	"github.com/spf13/cobra"
+)
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate FILE [FILE ...]",
+	Short: "Check config files for mistakes",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checkServer := mustGetCmd[bool](*cmd, "check-server")
+		var failed int
+		// Playlists on the same server must only be randomized once, even
+		// when split across files
+		playlists := map[string]*goflex.ConfigFile{}
+		for _, path := range args {
+			cfg, err := goflex.LoadConfig(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed++
+				continue
+			}
+			errs := []error{cfg.Validate()}
+			server, err := cfg.Config.WithServer(selectedServer())
+			if err != nil {
+				errs = append(errs, goflex.ConfigError{Path: cfg.Path, Msg: err.Error()})
+			}
+			for _, req := range cfg.Config.Randomize {
+				key := server.URL + "\x00" + string(req.Playlist)
+				if other, ok := playlists[key]; ok && other.Path != cfg.Path {
+					errs = append(errs, cfg.PlaylistError(req.Playlist,
+						fmt.Sprintf("duplicate playlist %q, also randomized at %v", req.Playlist, other.PlaylistLocation(req.Playlist)),
+					))
+					continue
+				}
+				playlists[key] = cfg
+			}
+			if checkServer && errs[0] == nil && err == nil {
+				p, err := goflex.New(goflex.WithFlexConfig(server))
+				if err != nil {
+					return err
+				}
+				errs = append(errs, cfg.CheckServer(p))
+			}
+			ok := true
+			for _, err := range errs {
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					ok = false
+				}
+			}
+			if !ok {
+				failed++
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "%v: ok\n", path)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%v of %v config files are invalid", failed, len(args))
+		}
+		return nil
+	},
+}
+
+func init() {
+	configValidateCmd.PersistentFlags().Bool("check-server", false, "Also check every show exists on the server")
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -12,7 +12,10 @@ var createPlaylistCmd = &cobra.Command{
 	Short: "Create a new playlist",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 		if err := p.Playlists.Create(goflex.PlaylistTitle(args[0]), "video", false); err != nil {
 			return err
 		}
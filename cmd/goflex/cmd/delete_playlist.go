@@ -13,7 +13,10 @@ var deletePlaylistCmd = &cobra.Command{
 	Short: "Delete a playlist",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		pl, err := p.Playlists.GetWithName(goflex.PlaylistTitle(args[0]))
 		if err != nil {
@@ -22,7 +22,10 @@ var deletePlaylistItemCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		pl, err := p.Playlists.GetWithName(goflex.PlaylistTitle(args[0]))
 		if err != nil {
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	goflex "github.com/drewstinnett/go-flex"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit SHOW [SEASON EPISODE]",
+	Short: "Edit the metadata of a show or episode",
+	Long: `Edit the metadata of a show or episode. With just a SHOW, every matching show is
+edited. With a SEASON and EPISODE, that episode is edited instead. Use --key to
+edit an item by its rating key directly.
+
+Edited fields are locked so agent refreshes don't overwrite them.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if mustGetCmd[int](*cmd, "key") != 0 {
+			return cobra.ExactArgs(0)(cmd, args)
+		}
+		if len(args) != 1 && len(args) != 3 {
+			return errors.New("requires SHOW or SHOW SEASON EPISODE")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		edit, err := metadataEditWithCmd(*cmd)
+		if err != nil {
+			return err
+		}
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
+
+		keys, err := editKeys(p, *cmd, args)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			slog.Debug("editing metadata", "key", key)
+			if err := p.Media.EditMetadata(key, *edit); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Edited %v item(s)!\n", len(keys))
+		return nil
+	},
+}
+
+func editKeys(p *goflex.Flex, cmd cobra.Command, args []string) ([]int, error) {
+	if key := mustGetCmd[int](cmd, "key"); key != 0 {
+		return []int{key}, nil
+	}
+	if len(args) == 3 {
+		show, season, episode, err := episodeArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		e, err := p.Shows.Episode(show, season, episode)
+		if err != nil {
+			return nil, err
+		}
+		return []int{e.ID}, nil
+	}
+	shows, err := p.Shows.StrictMatch(goflex.ShowTitle(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]int, len(shows))
+	for idx, show := range shows {
+		keys[idx] = show.ID
+	}
+	return keys, nil
+}
+
+func metadataEditWithCmd(cmd cobra.Command) (*goflex.MetadataEdit, error) {
+	edit := goflex.MetadataEdit{}
+	stringFlag := func(name string) *string {
+		if !cmd.Flags().Changed(name) {
+			return nil
+		}
+		return toPTR(mustGetCmd[string](cmd, name))
+	}
+	edit.Title = stringFlag("title")
+	edit.TitleSort = stringFlag("title-sort")
+	edit.Summary = stringFlag("summary")
+	edit.ContentRating = stringFlag("content-rating")
+	if available := stringFlag("originally-available-at"); available != nil {
+		got, err := time.Parse(time.DateOnly, *available)
+		if err != nil {
+			return nil, fmt.Errorf("invalid originally-available-at: %w", err)
+		}
+		edit.OriginallyAvailableAt = &got
+	}
+	for _, f := range mustGetCmd[[]string](cmd, "lock") {
+		edit.Lock = append(edit.Lock, goflex.MetadataField(f))
+	}
+	for _, f := range mustGetCmd[[]string](cmd, "unlock") {
+		edit.Unlock = append(edit.Unlock, goflex.MetadataField(f))
+	}
+	return &edit, nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.PersistentFlags().Int("key", 0, "rating key of the item to edit, instead of SHOW [SEASON EPISODE]")
+	editCmd.PersistentFlags().String("title", "", "new title")
+	editCmd.PersistentFlags().String("title-sort", "", "new title used for sorting")
+	editCmd.PersistentFlags().String("summary", "", "new summary")
+	editCmd.PersistentFlags().String("originally-available-at", "", "new original air date (YYYY-MM-DD)")
+	editCmd.PersistentFlags().String("content-rating", "", "new content rating, such as TV-14")
+	editCmd.PersistentFlags().StringSlice("lock", []string{}, "fields to lock without editing them")
+	editCmd.PersistentFlags().StringSlice("unlock", []string{}, "fields to unlock so agents may update them")
+}
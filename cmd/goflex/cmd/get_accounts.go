@@ -12,7 +12,10 @@ var getAccountsCmd = &cobra.Command{
 	Aliases: []string{"account"},
 	Args:    cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Server.Accounts()
 		if err != nil {
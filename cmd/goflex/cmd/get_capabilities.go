@@ -12,7 +12,10 @@ var getCapabilitiesCmd = &cobra.Command{
 	Aliases: []string{"cap", "capability"},
 	Args:    cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Server.Capabilities()
 		if err != nil {
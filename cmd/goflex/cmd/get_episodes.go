@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"errors"
 	"log/slog"
 	"time"
 
@@ -15,14 +14,14 @@ var getEpisodesCmd = &cobra.Command{
 	Short: "Get episodes",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := newPlex()
-
-		shows, err := p.Shows.Match(goflex.ShowTitle(args[0]))
+		p, err := newPlex()
 		if err != nil {
 			return err
 		}
-		if len(shows) == 0 {
-			return errors.New("show not found: " + args[0])
+
+		shows, err := p.Shows.StrictMatch(goflex.ShowTitle(args[0]))
+		if err != nil {
+			return err
 		}
 		short := mustGetCmd[bool](*cmd, "short")
 		wd := mustGetCmd[time.Duration](*cmd, "watch-duration")
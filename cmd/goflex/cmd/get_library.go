@@ -11,7 +11,10 @@ var getLibraryCmd = &cobra.Command{
 	Short: "Get library",
 	Args:  cobra.ExactArgs(0),
 	RunE: func(_ *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		items, err := p.Library.List()
 		if err != nil {
@@ -11,7 +11,10 @@ var getPlaylistEpisodesCmd = &cobra.Command{
 	Short: "Get all of the episodes in a given playlist",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		playlist, err := p.Playlists.GetWithName(goflex.PlaylistTitle(args[0]))
 		if err != nil {
@@ -11,7 +11,10 @@ var getPlaylistCmd = &cobra.Command{
 	Use:   "playlist [TITLE]",
 	Short: "Get a playlist from the API",
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		if len(args) == 0 {
 			ret, err := p.Playlists.List()
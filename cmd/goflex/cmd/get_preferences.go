@@ -12,7 +12,10 @@ var getPreferencesCmd = &cobra.Command{
 	Aliases: []string{"pref", "prefs", "preference"},
 	Args:    cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Server.Preferences()
 		if err != nil {
@@ -14,7 +14,10 @@ var getSeasonsCmd = &cobra.Command{
 	Short: "Get shows",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		shows, err := p.Shows.Match(goflex.ShowTitle(args[0]))
 		if err != nil {
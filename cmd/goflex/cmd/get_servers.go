@@ -12,7 +12,10 @@ var getServersCmd = &cobra.Command{
 	Aliases: []string{"server"},
 	Args:    cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Server.Servers()
 		if err != nil {
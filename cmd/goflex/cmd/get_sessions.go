@@ -20,7 +20,10 @@ var getSessionsCmd = &cobra.Command{
 	Use:   "sessions [SHOW ...[SHOW]]",
 	Short: "Get session information",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		var ret goflex.EpisodeList
 		if mustGetCmd[bool](*cmd, "history") {
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	goflex "github.com/drewstinnett/go-flex"
@@ -16,13 +18,17 @@ var getShowsCmd = &cobra.Command{
 	Aliases: []string{"show"},
 	Args:    cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		libs, err := p.Library.List()
 		if err != nil {
 			return err
 		}
 		wd := mustGetCmd[time.Duration](*cmd, "watch-duration")
+		progress := mustGetCmd[bool](*cmd, "progress")
 		var watch bool
 		if wd > 0 {
 			watch = true
@@ -37,7 +43,11 @@ var getShowsCmd = &cobra.Command{
 				if err != nil {
 					return err
 				}
-				gout.MustPrint(shows)
+				if progress {
+					printShowProgress(shows)
+				} else {
+					gout.MustPrint(shows)
+				}
 			}
 			if !watch {
 				return nil
@@ -47,7 +57,21 @@ var getShowsCmd = &cobra.Command{
 	},
 }
 
+func printShowProgress(shows goflex.ShowMap) {
+	titles := make([]goflex.ShowTitle, 0, len(shows))
+	for title := range shows {
+		titles = append(titles, title)
+	}
+	slices.Sort(titles)
+	for _, title := range titles {
+		show := shows[title]
+		fmt.Printf("%v: %.0f%% (%v/%v episodes, %v seasons)\n",
+			show.Title, show.Progress(), show.ViewedEpisodeCount, show.EpisodeCount, show.SeasonCount)
+	}
+}
+
 func init() {
 	getShowsCmd.PersistentFlags().DurationP("watch-duration", "w", 0, "get seasons again every duration")
+	getShowsCmd.PersistentFlags().BoolP("progress", "p", false, "print the watch progress of each show")
 	getCmd.AddCommand(getShowsCmd)
 }
@@ -12,7 +12,10 @@ var getTokenCmd = &cobra.Command{
 	Short: "Get a new token from username and password",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Authentication.Token(args[0], args[1])
 		if err != nil {
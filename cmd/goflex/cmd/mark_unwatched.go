@@ -13,7 +13,10 @@ var markUnWatchedCmd = &cobra.Command{
 	Aliases: []string{"unwatch", "unwatched"},
 	Args:    cobra.ExactArgs(3),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 		show, season, episode, err := episodeArgs(args)
 		if err != nil {
 			return err
@@ -15,7 +15,10 @@ var markWatchedCmd = &cobra.Command{
 	Aliases: []string{"watch", "watched"},
 	Args:    cobra.ExactArgs(3),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		show, season, episode, err := episodeArgs(args)
 		if err != nil {
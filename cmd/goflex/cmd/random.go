@@ -7,26 +7,35 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
 	goflex "github.com/drewstinnett/go-flex"
+	"github.com/drewstinnett/gout/v2"
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
-	"gopkg.in/yaml.v3"
 )
 
 // randomCmd represents the random command
 var randomCmd = &cobra.Command{
-	Use:   "random config-1.yaml [config-2.yaml ...]",
+	Use:   "random [config-1.yaml ...]",
 	Short: "Randomize a playlist using the given list of requests",
-	Args:  cobra.MinimumNArgs(1),
+	Long:  "Randomize playlists using the requests in the given config files, or in the CLI config when none are given",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			path, _ := cliConfigPath()
+			args = []string{path}
+		}
 		configs, flexes, err := loadConfigs(args)
 		if err != nil {
 			return err
 		}
+		state := goflex.NewStateStore(stateFilePath(*cmd))
+		dryRun := mustGetCmd[bool](*cmd, "dry-run")
+		if dryRun || mustGetCmd[bool](*cmd, "once") {
+			return randomizeOnce(configs, flexes, state, dryRun)
+		}
 
 		// Set up context with signal handling for graceful shutdown
 		ctx, cancel := context.WithCancel(context.Background())
@@ -40,17 +49,12 @@ var randomCmd = &cobra.Command{
 			cancel()
 		}()
 
-		errs, ctx := errgroup.WithContext(ctx)
-		for configIdx, config := range configs {
-			slog.Debug("got requests", "requests", config.Randomize)
-			if len(config.Randomize) == 0 {
-				slog.Warn("config has no randomize requests", "idx", configIdx)
-			}
-			for showIdx, request := range config.Randomize {
-				startRandomizer(ctx, errs, showIdx, request, flexes[configIdx])
-			}
-		}
-		err = errs.Wait()
+		// SIGHUP reloads the configs, as does any change to their files
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+
+		sup := newSupervisor(args, configs, flexes, state)
+		err = sup.run(ctx, reload, mustGetCmd[time.Duration](*cmd, "reload-interval"))
 		if err != nil && !errors.Is(err, context.Canceled) {
 			return err
 		}
@@ -60,9 +64,88 @@ var randomCmd = &cobra.Command{
 }
 
 func init() {
+	randomCmd.PersistentFlags().Bool("once", false, "Run each randomizer one time, print a summary and exit")
+	randomCmd.PersistentFlags().Bool("dry-run", false, "Print what each randomizer would change, without changing anything. Implies --once")
+	randomCmd.PersistentFlags().Duration("reload-interval", 30*time.Second, "How often to check the config files for changes. 0 only reloads on SIGHUP")
+	randomCmd.PersistentFlags().String("state", "", "File to remember each playlist's refills and schedule in, defaults to $XDG_STATE_HOME/goflex/state.json")
 	rootCmd.AddCommand(randomCmd)
 }
 
+// randomizeSummary is what gets printed for each request run with --once.
+type randomizeSummary struct {
+	Playlist     goflex.PlaylistTitle `json:"playlist" yaml:"playlist"`
+	Created      bool                 `json:"created,omitempty" yaml:"created,omitempty"`
+	RefillReason string               `json:"refill_reason,omitempty" yaml:"refill_reason,omitempty"`
+	Removed      int                  `json:"removed" yaml:"removed"`
+	Remaining    int                  `json:"remaining" yaml:"remaining"`
+	Added        int                  `json:"added" yaml:"added"`
+	Seed         *uint64              `json:"seed,omitempty" yaml:"seed,omitempty"`
+	NextCheck    string               `json:"next_check,omitempty" yaml:"next_check,omitempty"`
+	// RemovedEpisodes are the watched or duplicate episodes taken out of the
+	// playlist, or that would be with a dry run.
+	RemovedEpisodes []string `json:"removed_episodes,omitempty" yaml:"removed_episodes,omitempty"`
+	Planned         []string `json:"planned,omitempty" yaml:"planned,omitempty"`
+	Error           string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func newRandomizeSummary(req goflex.RandomizeRequest, resp *goflex.RandomizeResponse, err error) randomizeSummary {
+	ret := randomizeSummary{Playlist: req.Playlist}
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	ret.Created = resp.Created
+	ret.RefillReason = resp.RefillReason
+	ret.Removed = len(resp.Removed)
+	ret.Remaining = len(resp.Remaining)
+	ret.Added = len(resp.UnviewedEpisodes)
+	if resp.Refilled {
+		ret.Seed = &resp.Seed
+	}
+	if resp.SleepFor > 0 {
+		ret.NextCheck = resp.SleepFor.String()
+	}
+	ret.RemovedEpisodes = episodeStrings(resp.Removed)
+	ret.Planned = episodeStrings(resp.Planned)
+	return ret
+}
+
+// episodeStrings describes each episode in the list.
+func episodeStrings(episodes goflex.EpisodeList) []string {
+	var ret []string
+	for _, episode := range episodes {
+		ret = append(ret, episode.String())
+	}
+	return ret
+}
+
+// randomizeOnce runs every request a single time, prints a summary of each,
+// and returns an error if any of them failed.
+func randomizeOnce(configs []*goflex.ConfigFile, flexes []*goflex.Flex, state *goflex.StateStore, dryRun bool) error {
+	summaries := []randomizeSummary{}
+	var failed int
+	for configIdx, config := range configs {
+		for _, req := range config.Config.Randomize {
+			req.DryRun = req.DryRun || dryRun
+			resp, err := flexes[configIdx].Playlists.Randomize(req)
+			if err != nil {
+				slog.Error("randomize failed", "playlist", req.Playlist, "error", err)
+				failed++
+			} else {
+				recordState(state, config.Config.URL, req, resp)
+			}
+			summaries = append(summaries, newRandomizeSummary(req, resp, err))
+		}
+	}
+	if err := gout.Print(summaries); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v of %v randomize requests failed", failed, len(summaries))
+	}
+	return nil
+}
+
 const (
 	maxRetries       = 10
 	initialBackoff   = 30 * time.Second
@@ -84,6 +167,18 @@ func isFatalError(err error) bool {
 		"series muset not be empty", // typo preserved from original code
 		"must set plex baseurl",
 		"must set token",
+		"series must have a show or guid",
+		"must specify a show or guid",
+		"invalid specials policy",
+		"invalid episode selector",
+		"series weight must not be negative",
+		"unknown strategy",
+		"refill_below, target_length and max_items must not be negative",
+		"target_length must not be less than refill_below",
+		"max_items must be greater than refill_at",
+		"unknown refill mode",
+		"earliest_season must not be after latest_season",
+		"unknown server",
 	}
 	for _, pattern := range fatalPatterns {
 		if strings.Contains(errStr, pattern) {
@@ -107,87 +202,145 @@ func calculateBackoff(consecutiveErrors int) time.Duration {
 	return time.Duration(backoff)
 }
 
-func startRandomizer(ctx context.Context, g *errgroup.Group, idx int, req goflex.RandomizeRequest, f *goflex.Flex) {
-	g.Go(func() error {
-		logger := slog.With("show-idx", idx, "playlist", req.Playlist)
-		logger.Info("starting randomizer")
-
-		var consecutiveErrors int
+// runRandomizer randomizes the playlist until the context is cancelled or a
+// fatal error occurs. When resuming, it first waits for the next check saved
+// in the state store by an earlier run.
+func runRandomizer(ctx context.Context, idx int, req goflex.RandomizeRequest, f *goflex.Flex, state *goflex.StateStore, server string, resume bool) error {
+	logger := slog.With("show-idx", idx, "playlist", req.Playlist)
+	logger.Info("starting randomizer")
 
-		for {
+	if resume {
+		if wait := resumeDelay(state, server, req); wait > 0 {
+			logger.Info("resuming schedule from saved state", "next_check", wait)
 			select {
 			case <-ctx.Done():
-				logger.Info("randomizer stopping due to context cancellation")
+				logger.Info("randomizer stopping before resuming")
 				return ctx.Err()
-			default:
+			case <-time.After(wait):
 			}
+		}
+	}
 
-			resp, err := f.Playlists.Randomize(req)
-			if err != nil {
-				// Check if this is a fatal error that shouldn't be retried
-				if isFatalError(err) {
-					logger.Error("fatal error, stopping randomizer", "error", err)
-					return fmt.Errorf("fatal error for playlist %q: %w", req.Playlist, err)
-				}
-
-				consecutiveErrors++
-				backoff := calculateBackoff(consecutiveErrors)
-
-				if consecutiveErrors >= maxRetries {
-					logger.Error("max retries exceeded, stopping randomizer",
-						"error", err,
-						"consecutive_errors", consecutiveErrors)
-					return fmt.Errorf("max retries (%d) exceeded for playlist %q: %w", maxRetries, req.Playlist, err)
-				}
-
-				logger.Warn("randomize failed, will retry",
-					"error", err,
-					"consecutive_errors", consecutiveErrors,
-					"backoff", backoff)
-
-				select {
-				case <-ctx.Done():
-					logger.Info("randomizer stopping during backoff")
-					return ctx.Err()
-				case <-time.After(backoff):
-					continue
-				}
+	var consecutiveErrors int
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("randomizer stopping due to context cancellation")
+			return ctx.Err()
+		default:
+		}
+
+		resp, err := f.Playlists.Randomize(req)
+		if err != nil {
+			// Check if this is a fatal error that shouldn't be retried
+			if isFatalError(err) {
+				logger.Error("fatal error, stopping randomizer", "error", err)
+				return fmt.Errorf("fatal error for playlist %q: %w", req.Playlist, err)
 			}
 
-			// Success - reset error counter
-			if consecutiveErrors > 0 {
-				logger.Info("recovered after errors", "previous_consecutive_errors", consecutiveErrors)
+			consecutiveErrors++
+			backoff := calculateBackoff(consecutiveErrors)
+
+			if consecutiveErrors >= maxRetries {
+				logger.Error("max retries exceeded, stopping randomizer",
+					"error", err,
+					"consecutive_errors", consecutiveErrors)
+				return fmt.Errorf("max retries (%d) exceeded for playlist %q: %w", maxRetries, req.Playlist, err)
 			}
-			consecutiveErrors = 0
 
-			logger.Debug("sleeping until next check", "duration", resp.SleepFor)
+			logger.Warn("randomize failed, will retry",
+				"error", err,
+				"consecutive_errors", consecutiveErrors,
+				"backoff", backoff)
 
 			select {
 			case <-ctx.Done():
-				logger.Info("randomizer stopping during sleep")
+				logger.Info("randomizer stopping during backoff")
 				return ctx.Err()
-			case <-time.After(resp.SleepFor):
+			case <-time.After(backoff):
+				continue
 			}
 		}
-	})
+
+		// Success - reset error counter
+		if consecutiveErrors > 0 {
+			logger.Info("recovered after errors", "previous_consecutive_errors", consecutiveErrors)
+		}
+		consecutiveErrors = 0
+		recordState(state, server, req, resp)
+
+		logger.Debug("sleeping until next check", "duration", resp.SleepFor)
+
+		select {
+		case <-ctx.Done():
+			logger.Info("randomizer stopping during sleep")
+			return ctx.Err()
+		case <-time.After(resp.SleepFor):
+		}
+	}
 }
 
-func loadConfigs(paths []string) ([]goflex.FlexConfig, []*goflex.Flex, error) {
-	cfgs := make([]goflex.FlexConfig, 0, len(paths))
-	flexes := make([]*goflex.Flex, 0, len(paths))
+// resumeDelay returns how long until the saved next check of a playlist, or 0
+// if it's due, there's no saved state, or the request changed since it was
+// saved.
+func resumeDelay(state *goflex.StateStore, server string, req goflex.RandomizeRequest) time.Duration {
+	saved, ok, err := state.Get(server, req.Playlist)
+	if err != nil {
+		slog.Warn("could not read saved state", "state", state.Path(), "error", err)
+		return 0
+	}
+	if !ok || saved.Fingerprint != requestFingerprint(req) {
+		return 0
+	}
+	return time.Until(saved.NextCheck)
+}
 
-	for _, path := range paths {
-		data, err := os.ReadFile(path)
+// recordState saves the result of a randomize. Failing to save is logged, but
+// doesn't stop the randomizer.
+func recordState(state *goflex.StateStore, server string, req goflex.RandomizeRequest, resp *goflex.RandomizeResponse) {
+	if resp.DryRun {
+		return
+	}
+	saved, _, err := state.Get(server, req.Playlist)
+	if err == nil {
+		saved.Server, saved.Playlist = server, req.Playlist
+		saved.Fingerprint = requestFingerprint(req)
+		err = state.Put(saved.WithResponse(resp, time.Now()))
+	}
+	if err != nil {
+		slog.Warn("could not save state", "state", state.Path(), "playlist", req.Playlist, "error", err)
+	}
+}
+
+// stateFilePath returns the state file from --state, or the default in the
+// XDG state directory.
+func stateFilePath(cmd cobra.Command) string {
+	if path := mustGetCmd[string](cmd, "state"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, nil, fmt.Errorf("reading %q: %w", path, err)
+			return filepath.Join(os.TempDir(), "goflex", "state.json")
 		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "goflex", "state.json")
+}
+
+func loadConfigs(paths []string) ([]*goflex.ConfigFile, []*goflex.Flex, error) {
+	cfgs := make([]*goflex.ConfigFile, 0, len(paths))
+	flexes := make([]*goflex.Flex, 0, len(paths))
 
-		var cfg goflex.FlexConfig
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
-			return nil, nil, fmt.Errorf("parsing %q: %w", path, err)
+	for _, path := range paths {
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		flex, err := goflex.New(goflex.WithFlexConfig(cfg))
+		flex, err := goflex.New(goflex.WithFlexConfig(cfg.Config))
 		if err != nil {
 			return nil, nil, fmt.Errorf("initializing flex for %q: %w", path, err)
 		}
@@ -197,3 +350,26 @@ func loadConfigs(paths []string) ([]goflex.FlexConfig, []*goflex.Flex, error) {
 	}
 	return cfgs, flexes, nil
 }
+
+// loadConfig reads, strictly parses and validates a single config file, along
+// with any files it includes, and selects the server profile. The CLI config
+// also takes the environment and flag overrides.
+func loadConfig(path string) (*goflex.ConfigFile, error) {
+	cfg, err := goflex.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	// Validate the settings that will be used, so a url or token coming from
+	// a profile or the environment counts
+	cfg.Config, err = cfg.Config.WithServer(selectedServer())
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	if cliPath, _ := cliConfigPath(); path == cliPath {
+		cfg.Config = withOverrides(cfg.Config)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -17,7 +17,10 @@ var randomCmdDeprecated = &cobra.Command{
 	Short: "Randomize a playlist",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		// Inspect the playlist, create it if it doesn't exist
 		playlist, created, err := p.Playlists.GetOrCreate(goflex.PlaylistTitle(args[0]), goflex.VideoPlaylist, false)
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	goflex "github.com/drewstinnett/go-flex"
+	"github.com/drewstinnett/gout/v2"
+	"github.com/spf13/cobra"
+)
+
+// randomStatusCmd represents the random status command
+var randomStatusCmd = &cobra.Command{
+	Use:   "status [PLAYLIST ...]",
+	Short: "Show the last refill and next check saved for each randomized playlist",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		states, err := goflex.NewStateStore(stateFilePath(*cmd)).List()
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			wanted := map[goflex.PlaylistTitle]bool{}
+			for _, arg := range args {
+				wanted[goflex.PlaylistTitle(arg)] = true
+			}
+			filtered := []goflex.PlaylistState{}
+			for _, state := range states {
+				if wanted[state.Playlist] {
+					filtered = append(filtered, state)
+				}
+			}
+			states = filtered
+		}
+		return gout.Print(states)
+	},
+}
+
+func init() {
+	randomCmd.AddCommand(randomStatusCmd)
+}
@@ -4,8 +4,12 @@ Package cmd is the cmd stuff
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -14,8 +18,11 @@ import (
 )
 
 var (
-	verbose    bool
-	gcInterval *time.Duration = toPTR(time.Minute * 10)
+	verbose     bool
+	concurrency int
+	gcInterval  *time.Duration = toPTR(time.Minute * 10)
+	configFile  string
+	serverName  string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -31,13 +38,29 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		slog.Warn("fatal error", "error", err)
+		printSuggestions(err)
 		os.Exit(2)
 	}
 }
 
+// printSuggestions prints "did you mean..." hints when a show could not be found.
+func printSuggestions(err error) {
+	var notFound *goflex.ShowNotFoundError
+	if !errors.As(err, &notFound) || len(notFound.Suggestions) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Did you mean...")
+	for _, title := range notFound.Suggestions {
+		fmt.Fprintf(os.Stderr, "  %v\n", title)
+	}
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose logging")
 	rootCmd.PersistentFlags().DurationVar(gcInterval, "gc-interval", time.Minute*5, "garbage collection interval")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 4, "maximum number of requests to the server in flight at once")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file, defaults to $GOFLEX_CONFIG or $XDG_CONFIG_HOME/goflex/goflex.yaml")
+	rootCmd.PersistentFlags().StringVar(&serverName, "server", "", "server profile from the config to use, defaults to $GOFLEX_SERVER or default_server")
 	cobra.OnInitialize(initConfig)
 }
 
@@ -54,15 +77,76 @@ func initConfig() {
 	slog.SetDefault(logger)
 }
 
-func newPlex() *goflex.Flex {
-	opts := []func(*goflex.Flex){
-		goflex.WithBaseURL(os.Getenv("PLEX_URL")),
-		goflex.WithToken(os.Getenv("PLEX_TOKEN")),
-		goflex.WithGCInterval(gcInterval),
+// newPlex returns a client for the server in the CLI config.
+func newPlex() (*goflex.Flex, error) {
+	cfg, err := cliConfig()
+	if err != nil {
+		return nil, err
+	}
+	return goflex.New(goflex.WithFlexConfig(cfg))
+}
+
+// cliConfigPath returns the config file to use, and whether it was asked for
+// explicitly rather than being the default.
+func cliConfigPath() (string, bool) {
+	if configFile != "" {
+		return configFile, true
+	}
+	if path := os.Getenv("GOFLEX_CONFIG"); path != "" {
+		return path, true
 	}
-	p, err := goflex.New(opts...)
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		panic(err)
+		return "", false
+	}
+	return filepath.Join(dir, "goflex", "goflex.yaml"), false
+}
+
+// selectedServer returns the server profile asked for with --server or
+// GOFLEX_SERVER.
+func selectedServer() string {
+	if serverName != "" {
+		return serverName
+	}
+	return os.Getenv("GOFLEX_SERVER")
+}
+
+// cliConfig loads the config shared by every subcommand. A missing default
+// config file is fine, so PLEX_URL and PLEX_TOKEN alone still work. The
+// environment and flags override the file.
+func cliConfig() (goflex.FlexConfig, error) {
+	cfg := goflex.FlexConfig{}
+	path, explicit := cliConfigPath()
+	if path != "" {
+		file, err := goflex.LoadConfig(path)
+		switch {
+		case err == nil:
+			cfg = file.Config
+		case explicit || !errors.Is(err, fs.ErrNotExist):
+			return cfg, err
+		}
+	}
+	cfg, err := cfg.WithServer(selectedServer())
+	if err != nil {
+		return cfg, err
+	}
+	return withOverrides(cfg), nil
+}
+
+// withOverrides applies the PLEX_URL and PLEX_TOKEN environment variables and
+// the global flags to a config.
+func withOverrides(cfg goflex.FlexConfig) goflex.FlexConfig {
+	if url := os.Getenv("PLEX_URL"); url != "" {
+		cfg.URL = url
+	}
+	if token := os.Getenv("PLEX_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	if rootCmd.PersistentFlags().Changed("concurrency") || cfg.Concurrency == 0 {
+		cfg.Concurrency = concurrency
+	}
+	if rootCmd.PersistentFlags().Changed("gc-interval") || cfg.GarbageCollectionInterval == nil {
+		cfg.GarbageCollectionInterval = gcInterval
 	}
-	return p
+	return cfg
 }
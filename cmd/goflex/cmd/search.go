@@ -12,7 +12,10 @@ var searchCmd = &cobra.Command{
 	Short: "Search libraries for something",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(_ *cobra.Command, args []string) error {
-		p := newPlex()
+		p, err := newPlex()
+		if err != nil {
+			return err
+		}
 
 		got, err := p.Server.Search(args[0])
 		if err != nil {
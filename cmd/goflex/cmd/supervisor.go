@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	goflex "github.com/drewstinnett/go-flex"
+)
+
+// supervisor runs a randomizer for every request in a set of config files, and
+// reloads the files when asked, only restarting the randomizers that changed.
+type supervisor struct {
+	paths   []string
+	configs map[string]goflex.FlexConfig
+	flexes  map[string]*goflex.Flex
+	// files are the config file and its includes, and stamps their
+	// modification times when last loaded, keyed by config path.
+	files   map[string][]string
+	stamps  map[string]string
+	running map[string]*randomizer
+	state   *goflex.StateStore
+	errs    chan error
+}
+
+// randomizer is a single running randomizer goroutine.
+type randomizer struct {
+	playlist    goflex.PlaylistTitle
+	fingerprint string
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func newSupervisor(paths []string, configs []*goflex.ConfigFile, flexes []*goflex.Flex, state *goflex.StateStore) *supervisor {
+	s := &supervisor{
+		paths:   paths,
+		configs: map[string]goflex.FlexConfig{},
+		flexes:  map[string]*goflex.Flex{},
+		files:   map[string][]string{},
+		stamps:  map[string]string{},
+		running: map[string]*randomizer{},
+		state:   state,
+		errs:    make(chan error, 1),
+	}
+	for idx, path := range paths {
+		s.configs[path] = configs[idx].Config
+		s.flexes[path] = flexes[idx]
+		s.files[path] = configs[idx].Files()
+		s.stamps[path] = modTimes(s.files[path])
+	}
+	return s
+}
+
+// run starts the randomizers and keeps them in line with the config files
+// until the context is cancelled or a randomizer fails.
+func (s *supervisor) run(ctx context.Context, reload <-chan os.Signal, interval time.Duration) error {
+	defer s.stopAll()
+	for _, path := range s.paths {
+		if len(s.configs[path].Randomize) == 0 {
+			slog.Warn("config has no randomize requests", "config", path)
+		}
+		s.sync(ctx, path, true)
+	}
+
+	var poll <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-s.errs:
+			return err
+		case sig := <-reload:
+			slog.Info("received signal, reloading configs", "signal", sig)
+			s.reload(ctx, false)
+		case <-poll:
+			s.reload(ctx, true)
+		}
+	}
+}
+
+// reload reads the config files again, skipping files when onlyChanged is set
+// and neither they nor anything they include has a new modification time. Invalid configs are logged and the
+// randomizers already running for them are left alone.
+func (s *supervisor) reload(ctx context.Context, onlyChanged bool) {
+	for _, path := range s.paths {
+		stamp := modTimes(s.files[path])
+		if onlyChanged && stamp == s.stamps[path] {
+			continue
+		}
+		s.stamps[path] = stamp
+		file, err := loadConfig(path)
+		if err != nil {
+			slog.Error("not reloading invalid config", "config", path, "error", err)
+			continue
+		}
+		if files := file.Files(); !slices.Equal(files, s.files[path]) {
+			s.files[path] = files
+			s.stamps[path] = modTimes(files)
+		}
+		cfg := file.Config
+		old := s.configs[path]
+		if !sameServer(old, cfg) {
+			flex, err := goflex.New(goflex.WithFlexConfig(cfg))
+			if err != nil {
+				slog.Error("not reloading invalid config", "config", path, "error", err)
+				continue
+			}
+			slog.Info("server settings changed, restarting all randomizers", "config", path)
+			s.stopPath(path)
+			s.flexes[path] = flex
+		}
+		slog.Info("reloaded config", "config", path)
+		s.configs[path] = cfg
+		s.sync(ctx, path, false)
+	}
+}
+
+// sync starts, stops and replaces the randomizers for a config file so they
+// match its current requests. Randomizers started with resume set wait for the
+// next check saved by an earlier run.
+func (s *supervisor) sync(ctx context.Context, path string, resume bool) {
+	wanted := map[string]bool{}
+	seen := map[goflex.PlaylistTitle]int{}
+	for idx, req := range s.configs[path].Randomize {
+		key := randomizerKey(path, req.Playlist, seen[req.Playlist])
+		seen[req.Playlist]++
+		wanted[key] = true
+		fingerprint := requestFingerprint(req)
+		if r, ok := s.running[key]; ok {
+			if r.fingerprint == fingerprint {
+				continue
+			}
+			slog.Info("request changed, restarting randomizer", "playlist", req.Playlist)
+			s.stop(key)
+		}
+		s.start(ctx, key, idx, req, s.flexes[path], s.configs[path].URL, resume)
+	}
+	for key, r := range s.running {
+		if keyPath(key) == path && !wanted[key] {
+			slog.Info("request removed, stopping randomizer", "playlist", r.playlist)
+			s.stop(key)
+		}
+	}
+}
+
+func (s *supervisor) start(ctx context.Context, key string, idx int, req goflex.RandomizeRequest, f *goflex.Flex, server string, resume bool) {
+	rctx, cancel := context.WithCancel(ctx)
+	r := &randomizer{
+		playlist:    req.Playlist,
+		fingerprint: requestFingerprint(req),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	s.running[key] = r
+	go func() {
+		defer close(r.done)
+		if err := runRandomizer(rctx, idx, req, f, s.state, server, resume); err != nil && !errors.Is(err, context.Canceled) {
+			select {
+			case s.errs <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// stop cancels a randomizer and waits for it to finish.
+func (s *supervisor) stop(key string) {
+	r, ok := s.running[key]
+	if !ok {
+		return
+	}
+	r.cancel()
+	<-r.done
+	delete(s.running, key)
+}
+
+// stopPath stops every randomizer started from a config file.
+func (s *supervisor) stopPath(path string) {
+	for key := range s.running {
+		if keyPath(key) == path {
+			s.stop(key)
+		}
+	}
+}
+
+func (s *supervisor) stopAll() {
+	for key := range s.running {
+		s.stop(key)
+	}
+}
+
+// randomizerKey identifies a request across reloads by its config file and
+// playlist, so moving requests around in the file doesn't restart them. n
+// counts earlier requests for the same playlist in the file.
+func randomizerKey(path string, playlist goflex.PlaylistTitle, n int) string {
+	return fmt.Sprintf("%v\x00%v\x00%v", path, playlist, n)
+}
+
+func keyPath(key string) string {
+	path, _, _ := strings.Cut(key, "\x00")
+	return path
+}
+
+// requestFingerprint returns a string that changes whenever the request does.
+// It's a hash so it stays short in the state file.
+func requestFingerprint(req goflex.RandomizeRequest) string {
+	b, err := json.Marshal(req)
+	if err != nil {
+		b = fmt.Appendf(nil, "%+v", req)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// sameServer returns true if two configs talk to the server the same way, so
+// the existing client and its caches can be kept.
+func sameServer(a, b goflex.FlexConfig) bool {
+	a.Randomize, b.Randomize = nil, nil
+	a.Include, b.Include = nil, nil
+	a.Servers, b.Servers = nil, nil
+	a.DefaultServer, b.DefaultServer = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// modTimes returns a string that changes whenever any of the files does.
+func modTimes(paths []string) string {
+	ret := make([]string, len(paths))
+	for idx, path := range paths {
+		ret[idx] = modTime(path).String()
+	}
+	return strings.Join(ret, ",")
+}
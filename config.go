@@ -0,0 +1,457 @@
+package goflex
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is a problem with a config file, pointing at the line causing it.
+type ConfigError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+// Error fulfills the error interface.
+func (e ConfigError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%v: %v", e.Path, e.Msg)
+	default:
+		return fmt.Sprintf("%v:%v: %v", e.Path, e.Line, e.Msg)
+	}
+}
+
+// ConfigErrors are all the problems found in a config file.
+type ConfigErrors []ConfigError
+
+// Error fulfills the error interface, listing one problem per line.
+func (e ConfigErrors) Error() string {
+	ret := make([]string, len(e))
+	for idx, item := range e {
+		ret[idx] = item.Error()
+	}
+	return strings.Join(ret, "\n")
+}
+
+// err returns the errors as an error, or nil if there are none.
+func (e ConfigErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ConfigFile is a parsed config file, remembering where each setting came from
+// so problems can be reported by line.
+type ConfigFile struct {
+	Path   string
+	Config FlexConfig
+	node   *yaml.Node
+	// requests records the file and node each randomize request came from,
+	// which differs from Path for requests pulled in with include.
+	requests []configSource
+}
+
+// configSource is where part of a config was read from.
+type configSource struct {
+	path string
+	node *yaml.Node
+}
+
+// LoadConfig reads and strictly parses a config file.
+func LoadConfig(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(path, data)
+}
+
+// yamlLineError matches the line number yaml puts in front of its errors.
+var yamlLineError = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// ParseConfig strictly parses config data, so unknown keys, such as typos, are
+// errors. Values may reference environment variables as ${NAME}, or
+// ${NAME:-default}, and $${ is a literal ${. Files listed under include hold
+// lists of randomize requests to add to the config, and are found relative to
+// the config. Errors are returned as ConfigErrors.
+func ParseConfig(path string, data []byte) (*ConfigFile, error) {
+	node, err := parseConfigNode(path, data, reflect.TypeFor[FlexConfig]())
+	if err != nil {
+		return nil, err
+	}
+	ret := &ConfigFile{Path: path, node: node}
+	if err := node.Decode(&ret.Config); err != nil {
+		return nil, yamlConfigErrors(path, err)
+	}
+	for _, item := range sequenceItems(childNode(ret.root(), "randomize")) {
+		ret.requests = append(ret.requests, configSource{path: path, node: item})
+	}
+
+	errs := ConfigErrors{}
+	for idx, include := range ret.Config.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		fragment, err := os.ReadFile(include)
+		if err != nil {
+			errs = append(errs, ret.errorAt(err.Error(), "include", idx))
+			continue
+		}
+		node, err := parseConfigNode(include, fragment, reflect.TypeFor[RandomizeRequestList]())
+		if err != nil {
+			errs = append(errs, err.(ConfigErrors)...)
+			continue
+		}
+		var reqs RandomizeRequestList
+		if err := node.Decode(&reqs); err != nil {
+			errs = append(errs, yamlConfigErrors(include, err)...)
+			continue
+		}
+		ret.Config.Randomize = append(ret.Config.Randomize, reqs...)
+		for _, item := range sequenceItems(documentRoot(node)) {
+			ret.requests = append(ret.requests, configSource{path: include, node: item})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ret, nil
+}
+
+// Files returns the config file and every file it includes.
+func (c ConfigFile) Files() []string {
+	ret := []string{c.Path}
+	for _, req := range c.requests {
+		if !slices.Contains(ret, req.path) {
+			ret = append(ret, req.path)
+		}
+	}
+	return ret
+}
+
+// parseConfigNode parses data into a node, interpolates environment variables
+// and checks every key is known to the given type.
+func parseConfigNode(path string, data []byte, t reflect.Type) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, yamlConfigErrors(path, err)
+	}
+	errs := interpolateNode(path, node)
+	errs = append(errs, unknownKeys(path, documentRoot(node), t)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return node, nil
+}
+
+// envReference matches ${NAME} and ${NAME:-default}, and the $${ escape.
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolateNode replaces environment variable references in every scalar
+// value under node.
+func interpolateNode(path string, node *yaml.Node) ConfigErrors {
+	errs := ConfigErrors{}
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${") {
+			return errs
+		}
+		node.Value = envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			m := envReference.FindStringSubmatch(ref)
+			if v, ok := os.LookupEnv(m[1]); ok {
+				return v
+			}
+			if strings.Contains(ref, ":-") {
+				return m[2]
+			}
+			errs = append(errs, ConfigError{Path: path, Line: node.Line, Msg: fmt.Sprintf("environment variable %v is not set", m[1])})
+			return ref
+		})
+		// Let the interpolated value decode as whatever type it looks like
+		node.Tag = ""
+		node.Style = 0
+		return errs
+	}
+	for idx, child := range node.Content {
+		// Only values are interpolated, never mapping keys
+		if node.Kind == yaml.MappingNode && idx%2 == 0 {
+			continue
+		}
+		errs = append(errs, interpolateNode(path, child)...)
+	}
+	return errs
+}
+
+// unknownKeys returns an error for every mapping key under node that doesn't
+// match a yaml key of the Go type it decodes into.
+func unknownKeys(path string, node *yaml.Node, t reflect.Type) ConfigErrors {
+	errs := ConfigErrors{}
+	if node == nil {
+		return errs
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[yaml.Unmarshaler]()) ||
+		reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return errs
+	}
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := yamlField(t, key.Value)
+			if !ok {
+				errs = append(errs, ConfigError{
+					Path: path,
+					Line: key.Line,
+					Msg:  fmt.Sprintf("field %v not found in type %v", key.Value, t),
+				})
+				continue
+			}
+			errs = append(errs, unknownKeys(path, value, field.Type)...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			errs = append(errs, unknownKeys(path, node.Content[i], t.Elem())...)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			errs = append(errs, unknownKeys(path, item, t.Elem())...)
+		}
+	}
+	return errs
+}
+
+// yamlField returns the struct field decoded from the given yaml key.
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlConfigErrors converts a yaml error to ConfigErrors.
+func yamlConfigErrors(path string, err error) ConfigErrors {
+	msgs := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+	ret := ConfigErrors{}
+	for _, msg := range msgs {
+		item := ConfigError{Path: path, Msg: msg}
+		if m := yamlLineError.FindStringSubmatch(msg); m != nil {
+			item.Line, _ = strconv.Atoi(m[1])
+			item.Msg = m[2]
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// Validate checks the config makes sense beyond being well formed: every
+// request is valid, refills have a threshold, and no playlist is randomized
+// by more than one request.
+func (c ConfigFile) Validate() error {
+	errs := ConfigErrors{}
+	// A default server profile stands in for the top level url and token
+	if c.Config.DefaultServer == "" {
+		unless := ""
+		if len(c.Config.Servers) > 0 {
+			unless = " unless default_server is set"
+		}
+		if c.Config.URL == "" {
+			errs = append(errs, c.errorAt("url must be set"+unless, "url"))
+		}
+		if c.Config.Token == "" {
+			errs = append(errs, c.errorAt("token must be set"+unless, "token"))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Config.Servers)) {
+		server := c.Config.Servers[name]
+		if server.URL == "" {
+			errs = append(errs, c.errorAt(fmt.Sprintf("url must be set for server %q", name), "servers", name, "url"))
+		}
+		if server.Token == "" {
+			errs = append(errs, c.errorAt(fmt.Sprintf("token must be set for server %q", name), "servers", name, "token"))
+		}
+	}
+	if _, err := c.Config.WithServer(""); err != nil {
+		errs = append(errs, c.errorAt(err.Error(), "default_server"))
+	}
+	if c.Config.Concurrency < 0 {
+		errs = append(errs, c.errorAt("concurrency must not be negative", "concurrency"))
+	}
+	playlists := map[PlaylistTitle]int{}
+	for idx, req := range c.Config.Randomize {
+		if err := req.Validate(); err != nil {
+			// Point at the setting causing the error, when we know it
+			var field *fieldError
+			var path []any
+			if errors.As(err, &field) {
+				path = field.path
+			}
+			errs = append(errs, c.requestErrorAt(idx, err.Error(), path...))
+		}
+		if (req.RefillAt <= 0) && (req.RefillBelow == 0) {
+			errs = append(errs, c.requestErrorAt(idx, "refill_at must be greater than 0 unless refill_below is set"))
+		}
+		for sidx, series := range req.Series {
+			if series.LookbackDays < 0 {
+				errs = append(errs, c.requestErrorAt(idx, "lookback_days must not be negative", "series", sidx, "lookback_days"))
+			}
+		}
+		if first, ok := playlists[req.Playlist]; ok {
+			errs = append(errs, c.requestErrorAt(idx,
+				fmt.Sprintf("duplicate playlist %q, also randomized at %v", req.Playlist, c.requestLocation(first)),
+				"playlist",
+			))
+			continue
+		}
+		playlists[req.Playlist] = idx
+	}
+	return errs.err()
+}
+
+// CheckServer checks the config against the server, making sure every show
+// it randomizes exists.
+func (c ConfigFile) CheckServer(p *Flex) error {
+	errs := ConfigErrors{}
+	for idx, req := range c.Config.Randomize {
+		for sidx, series := range req.Series {
+			if _, err := p.Shows.Resolve(series.Filter); err != nil {
+				errs = append(errs, c.requestErrorAt(idx, err.Error(), "series", sidx, "episodes"))
+			}
+		}
+	}
+	return errs.err()
+}
+
+// PlaylistLocation returns the file and line, as file:line, the request for a
+// playlist starts on, or an empty string if the config doesn't randomize it.
+func (c ConfigFile) PlaylistLocation(playlist PlaylistTitle) string {
+	for idx, req := range c.Config.Randomize {
+		if req.Playlist == playlist {
+			return c.requestLocation(idx)
+		}
+	}
+	return ""
+}
+
+// PlaylistError returns an error pointing at the request for a playlist.
+func (c ConfigFile) PlaylistError(playlist PlaylistTitle, msg string) ConfigError {
+	for idx, req := range c.Config.Randomize {
+		if req.Playlist == playlist {
+			return c.requestErrorAt(idx, msg, "playlist")
+		}
+	}
+	return ConfigError{Path: c.Path, Msg: msg}
+}
+
+// requestLocation returns the file and line the playlist of a request is on.
+func (c ConfigFile) requestLocation(idx int) string {
+	err := c.requestErrorAt(idx, "", "playlist")
+	return fmt.Sprintf("%v:%v", err.Path, err.Line)
+}
+
+func (c ConfigFile) errorAt(msg string, path ...any) ConfigError {
+	return ConfigError{Path: c.Path, Line: lineOf(c.root(), path...), Msg: msg}
+}
+
+// requestErrorAt returns an error pointing at the path under a randomize
+// request, in whichever file the request came from.
+func (c ConfigFile) requestErrorAt(idx int, msg string, path ...any) ConfigError {
+	if idx >= len(c.requests) {
+		return ConfigError{Path: c.Path, Msg: msg}
+	}
+	src := c.requests[idx]
+	return ConfigError{Path: src.path, Line: lineOf(src.node, path...), Msg: msg}
+}
+
+// root returns the top level node of the config.
+func (c ConfigFile) root() *yaml.Node {
+	return documentRoot(c.node)
+}
+
+// documentRoot returns the content of a document node.
+func documentRoot(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+	return node
+}
+
+// lineOf returns the line of the node at the given path of mapping keys and
+// sequence indexes under node, or of the deepest node found along the way.
+func lineOf(node *yaml.Node, path ...any) int {
+	if node == nil {
+		return 0
+	}
+	for _, step := range path {
+		next := childNode(node, step)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node.Line
+}
+
+// childNode returns the value for a key of a mapping node, or the item at an
+// index of a sequence node.
+func childNode(node *yaml.Node, step any) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	switch s := step.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == s {
+				return node.Content[i+1]
+			}
+		}
+	case int:
+		if node.Kind == yaml.SequenceNode && s < len(node.Content) {
+			return node.Content[s]
+		}
+	}
+	return nil
+}
+
+// sequenceItems returns the items of a sequence node, or nothing for any other
+// kind of node.
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
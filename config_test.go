@@ -0,0 +1,220 @@
+package goflex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigStrict(t *testing.T) {
+	_, err := ParseConfig("bad.yaml", []byte(`url: http://plex.example.com
+token: test-token
+randomize:
+  - playlist: Foo
+    refil_at: 3
+    series:
+      - lookback: 3
+        episodes:
+          show: Bar
+`))
+	require.EqualError(t, err, `bad.yaml:5: field refil_at not found in type goflex.RandomizeRequest
+bad.yaml:7: field lookback not found in type goflex.RandomizeSeries`)
+
+	_, err = ParseConfig("broken.yaml", []byte("url: [\n"))
+	require.Error(t, err)
+	var errs ConfigErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, "broken.yaml", errs[0].Path)
+}
+
+func TestParseConfigEnv(t *testing.T) {
+	t.Setenv("GOFLEX_TEST_TOKEN", "secret")
+	t.Setenv("GOFLEX_TEST_REFILL", "4")
+	got, err := ParseConfig("env.yaml", []byte(`url: ${GOFLEX_TEST_URL:-http://plex.example.com}
+token: ${GOFLEX_TEST_TOKEN}
+randomize:
+  - playlist: "$${NOT_A_VAR} ${GOFLEX_TEST_TOKEN}"
+    refill_at: ${GOFLEX_TEST_REFILL}
+    series:
+      - episodes:
+          show: Bar
+`))
+	require.NoError(t, err)
+	require.Equal(t, "http://plex.example.com", got.Config.URL)
+	require.Equal(t, "secret", got.Config.Token)
+	require.Equal(t, PlaylistTitle("${NOT_A_VAR} secret"), got.Config.Randomize[0].Playlist)
+	require.Equal(t, 4, got.Config.Randomize[0].RefillAt)
+
+	_, err = ParseConfig("env.yaml", []byte(`url: http://plex.example.com
+token: ${GOFLEX_TEST_MISSING}
+`))
+	require.EqualError(t, err, "env.yaml:2: environment variable GOFLEX_TEST_MISSING is not set")
+}
+
+func TestParseConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared.yaml"), []byte(`- playlist: Shared
+  refill_at: 3
+  series:
+    - lookback_days: -1
+      episodes:
+        show: Bar
+`), 0o600))
+	path := filepath.Join(dir, "goflex.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`url: http://plex.example.com
+token: test-token
+include:
+  - shared.yaml
+randomize:
+  - playlist: Local
+    refill_at: 3
+    series:
+      - episodes:
+          show: Bar
+`), 0o600))
+	got, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, got.Config.Randomize, 2)
+	require.Equal(t, PlaylistTitle("Local"), got.Config.Randomize[0].Playlist)
+	require.Equal(t, PlaylistTitle("Shared"), got.Config.Randomize[1].Playlist)
+	require.Equal(t, []string{path, filepath.Join(dir, "shared.yaml")}, got.Files())
+	require.Equal(t, filepath.Join(dir, "shared.yaml")+":1", got.PlaylistLocation("Shared"))
+	require.EqualError(t, got.Validate(), filepath.Join(dir, "shared.yaml")+":4: lookback_days must not be negative")
+
+	require.NoError(t, os.WriteFile(path, []byte(`url: http://plex.example.com
+token: test-token
+include:
+  - missing.yaml
+`), 0o600))
+	_, err = LoadConfig(path)
+	require.ErrorContains(t, err, path+":4: open "+filepath.Join(dir, "missing.yaml"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	got, err := LoadConfig("examples/goflex.yaml")
+	require.NoError(t, err)
+	require.NoError(t, got.Validate())
+
+	got, err = ParseConfig("bad.yaml", []byte(`url: http://plex.example.com
+randomize:
+  - playlist: Foo
+    series:
+      - episodes:
+          show: Bar
+  - playlist: Foo
+    refill_at: 3
+    series:
+      - lookback_days: -1
+        episodes:
+          show: Bar
+          earliest_season: 4
+          latest_season: 2
+`))
+	require.NoError(t, err)
+	require.EqualError(t, got.Validate(), `bad.yaml:1: token must be set
+bad.yaml:3: refill_at must be greater than 0 unless refill_below is set
+bad.yaml:13: earliest_season must not be after latest_season
+bad.yaml:10: lookback_days must not be negative
+bad.yaml:7: duplicate playlist "Foo", also randomized at bad.yaml:3`)
+	require.Equal(t, "bad.yaml:3", got.PlaylistLocation("Foo"))
+
+	// Request errors point at the setting causing them
+	got, err = ParseConfig("fields.yaml", []byte(`url: http://plex.example.com
+token: test-token
+randomize:
+  - playlist: Foo
+    refill_at: 3
+    strategy: backwards
+    series:
+      - episodes:
+          show: Bar
+  - playlist: Baz
+    refill_at: 3
+    mode: sideways
+    series:
+      - episodes:
+          show: Bar
+`))
+	require.NoError(t, err)
+	require.EqualError(t, got.Validate(), `fields.yaml:6: unknown strategy: backwards
+fields.yaml:12: unknown refill mode: sideways`)
+	require.Equal(t, "", got.PlaylistLocation("Bar"))
+}
+
+func TestConfigCheckServer(t *testing.T) {
+	svr := showsServer(t)
+	defer svr.Close()
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	got, err := ParseConfig("shows.yaml", []byte(`randomize:
+  - playlist: Foo
+    refill_at: 3
+    series:
+      - episodes:
+          show: American Dad!
+      - episodes:
+          show: Not A Real Show
+`))
+	require.NoError(t, err)
+	require.EqualError(t, got.CheckServer(p), "shows.yaml:8: show does not exist: Not A Real Show")
+}
+
+func TestConfigServers(t *testing.T) {
+	got, err := ParseConfig("servers.yaml", []byte(`default_server: home
+servers:
+  home:
+    url: http://home.example.com
+    token: home-token
+  cabin:
+    url: http://cabin.example.com
+  typo:
+    tokn: test-token
+`))
+	require.EqualError(t, err, "servers.yaml:9: field tokn not found in type goflex.ServerConfig")
+
+	got, err = ParseConfig("servers.yaml", []byte(`default_server: home
+servers:
+  home:
+    url: http://home.example.com
+    token: home-token
+  cabin:
+    url: http://cabin.example.com
+`))
+	require.NoError(t, err)
+	require.EqualError(t, got.Validate(), `servers.yaml:7: token must be set for server "cabin"`)
+
+	cfg, err := got.Config.WithServer("")
+	require.NoError(t, err)
+	require.Equal(t, "http://home.example.com", cfg.URL)
+	require.Equal(t, "home-token", cfg.Token)
+
+	cfg, err = got.Config.WithServer("cabin")
+	require.NoError(t, err)
+	require.Equal(t, "http://cabin.example.com", cfg.URL)
+
+	_, err = got.Config.WithServer("nope")
+	require.EqualError(t, err, "unknown server: nope")
+
+	// Without a default, the top level url and token are still needed
+	got, err = ParseConfig("servers.yaml", []byte(`servers:
+  home:
+    url: http://home.example.com
+    token: home-token
+`))
+	require.NoError(t, err)
+	require.ErrorContains(t, got.Validate(), "url must be set unless default_server is set")
+	require.ErrorContains(t, got.Validate(), "token must be set unless default_server is set")
+
+	got, err = ParseConfig("servers.yaml", []byte(`url: http://plex.example.com
+token: test-token
+servers:
+  home:
+    url: http://home.example.com
+    token: home-token
+`))
+	require.NoError(t, err)
+	require.NoError(t, got.Validate())
+}
@@ -6,7 +6,10 @@ import (
 	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Episode represents an episode of television
@@ -15,12 +18,68 @@ type Episode struct {
 	PlaylistItemID int
 	Title          string
 	Show           ShowTitle
+	// ShowID is the rating key of the episode's show, or 0 when unknown.
+	ShowID         int
 	Season         SeasonNumber
 	Episode        EpisodeNumber
 	Watched        *time.Time
 	ViewCount      int
 	ViewOffset     *time.Duration
 	Duration       time.Duration
+	ContentRating  string
+	Year           int
+	AudienceRating float64
+	Labels         []string
+	// AbsoluteIndex is the episode's number counted across all seasons, or 0
+	// when unknown.
+	AbsoluteIndex int
+	Media         []EpisodeMedia
+}
+
+// EpisodeMedia is one version of an episode available on the server.
+type EpisodeMedia struct {
+	ID              int
+	Duration        time.Duration
+	Container       string
+	VideoResolution string
+	Parts           []MediaPart
+}
+
+// MediaPart is a single file making up a media version.
+type MediaPart struct {
+	ID       int
+	File     string
+	Size     int64
+	Duration time.Duration
+}
+
+// Files returns the paths of the files backing the episode.
+func (e Episode) Files() []string {
+	ret := []string{}
+	for _, m := range e.Media {
+		for _, part := range m.Parts {
+			if part.File != "" {
+				ret = append(ret, part.File)
+			}
+		}
+	}
+	return ret
+}
+
+// fileKey identifies the files an episode plays from, so episodes stored
+// together in one multi-episode file can be told apart from separate ones.
+// Every part of every version counts, so episodes only match when they play
+// from exactly the same files. Returns an empty string when no file
+// information is known.
+func (e Episode) fileKey() string {
+	files := e.Files()
+	slices.Sort(files)
+	return strings.Join(files, "\x00")
+}
+
+// HasLabel returns true if the episode is tagged with the given label.
+func (e Episode) HasLabel(label string) bool {
+	return containsFold(e.Labels, label)
 }
 
 // EpisodeNumber is the number of an episode
@@ -47,9 +106,77 @@ func (e EpisodeMap) List() EpisodeList {
 
 // EpisodeFilter defines the filters the returned episodes
 type EpisodeFilter struct {
-	Show           ShowTitle    `yaml:"show"`
+	Show ShowTitle `yaml:"show"`
+	// GUID identifies the show by Plex or external GUID instead of by title,
+	// such as tvdb://71663. Takes precedence over Show when both are set.
+	GUID           string       `yaml:"guid"`
 	EarliestSeason SeasonNumber `yaml:"earliest_season"`
 	LatestSeason   SeasonNumber `yaml:"latest_season"`
+	// Specials controls whether season 0 is returned. When unset, specials
+	// are only returned if they fall within the season range.
+	Specials SpecialsPolicy `yaml:"specials"`
+}
+
+// SpecialsPolicy describes how specials (season 0) are filtered.
+type SpecialsPolicy string
+
+const (
+	// SpecialsInclude returns specials regardless of the season range.
+	SpecialsInclude SpecialsPolicy = "include"
+	// SpecialsExclude never returns specials.
+	SpecialsExclude SpecialsPolicy = "exclude"
+	// SpecialsOnly returns only specials.
+	SpecialsOnly SpecialsPolicy = "only"
+)
+
+// validate returns an error if the policy is not a known value.
+func (s SpecialsPolicy) validate() error {
+	switch s {
+	case "", SpecialsInclude, SpecialsExclude, SpecialsOnly:
+		return nil
+	default:
+		return fmt.Errorf("invalid specials policy: %v", s)
+	}
+}
+
+// UnmarshalYAML rejects unknown specials policies.
+func (s *SpecialsPolicy) UnmarshalYAML(value *yaml.Node) error {
+	var v string
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	if err := SpecialsPolicy(v).validate(); err != nil {
+		return err
+	}
+	*s = SpecialsPolicy(v)
+	return nil
+}
+
+// includesSeason returns true if episodes in the given season pass the filter.
+func (f EpisodeFilter) includesSeason(n SeasonNumber) bool {
+	switch {
+	case f.Specials == SpecialsOnly:
+		return n == 0
+	case n == 0 && f.Specials == SpecialsInclude:
+		return true
+	case n == 0 && f.Specials == SpecialsExclude:
+		return false
+	}
+	if (f.EarliestSeason != 0) && (n < f.EarliestSeason) {
+		return false
+	}
+	if (f.LatestSeason != 0) && (n > f.LatestSeason) {
+		return false
+	}
+	return true
+}
+
+// String returns the GUID or title the filter identifies a show by.
+func (f EpisodeFilter) String() string {
+	if f.GUID != "" {
+		return f.GUID
+	}
+	return string(f.Show)
 }
 
 // EpisodeList is multiple Episodes
@@ -81,6 +208,44 @@ func (l EpisodeList) LatestWatched() *Episode {
 	return ret
 }
 
+// filter returns the episodes in the list passing the filter.
+func (l EpisodeList) filter(f EpisodeFilter) EpisodeList {
+	ret := EpisodeList{}
+	for _, episode := range l {
+		if f.includesSeason(episode.Season) {
+			ret = append(ret, episode)
+		}
+	}
+	return ret
+}
+
+// OfShows returns the episodes in the list belonging to the given shows. Shows
+// are matched by ID, so same-titled shows in other libraries are left out, and
+// only by title when an episode doesn't know its show ID.
+func (l EpisodeList) OfShows(shows ShowList) EpisodeList {
+	ret := EpisodeList{}
+	for _, episode := range l {
+		if slices.ContainsFunc(shows, func(show *Show) bool {
+			if episode.ShowID != 0 {
+				return episode.ShowID == show.ID
+			}
+			return episode.Show == show.Title
+		}) {
+			ret = append(ret, episode)
+		}
+	}
+	return ret
+}
+
+// Runtime returns the total time left to watch the episodes in the list.
+func (l EpisodeList) Runtime() time.Duration {
+	var ret time.Duration
+	for _, episode := range l {
+		ret += episode.Remaining()
+	}
+	return ret
+}
+
 // Len returns the length of the list, to satisfy the sortable interface
 func (l EpisodeList) Len() int {
 	return len(l)
@@ -99,50 +264,88 @@ func (l EpisodeList) Less(i, j int) bool {
 	return l[i].Episode < l[j].Episode
 }
 
-// Seasons returns episodes matching a season start and stop
-func (l EpisodeList) Seasons(start, end SeasonNumber) EpisodeList {
-	ret := EpisodeList{}
-	// If no start/end specified, return everything
-	if (start == 0) && (end == 0) {
-		return l
+// Seasons returns episodes matching a season start and stop. An optional
+// SpecialsPolicy controls whether specials (season 0) are returned.
+func (l EpisodeList) Seasons(start, end SeasonNumber, specials ...SpecialsPolicy) EpisodeList {
+	f := EpisodeFilter{EarliestSeason: start, LatestSeason: end}
+	if len(specials) > 0 {
+		f.Specials = specials[0]
 	}
-	for _, ep := range l {
-		switch {
-		// If we just have a start
-		case ((start > 0) && (end == 0)) && (ep.Season >= start):
-			ret = append(ret, ep)
-		// If we just have an end
-		case ((end > 0) && (start == 0)) && (ep.Season <= end):
-			ret = append(ret, ep)
-		// If we have a start and and end
-		case (ep.Season >= start) && (ep.Season <= end):
-			ret = append(ret, ep)
-		}
+	// If no start/end or specials policy specified, return everything
+	if (start == 0) && (end == 0) && (f.Specials == "") {
+		return l
 	}
-	return ret
+	return l.filter(f)
 }
 
-// Subtract removes items from a list. Returns the edited list, and a list of episodes that were removed
+// Subtract removes items from a list. Returns the edited list, and a list of
+// episodes that were removed. An episode is removed when it shares a file with
+// any episode in s, or with an earlier episode in the list, so a duplicate
+// playlist item gets deleted too.
 func (l *EpisodeList) Subtract(s EpisodeList) (EpisodeList, EpisodeList) {
 	seenSlugs := []string{}
+	seenFiles := map[string]bool{}
 	r := EpisodeList{}
 	removed := EpisodeList{}
 	slugs := s.slugs()
+	files := s.fileKeys()
 	for _, item := range *l {
 		slug := item.slug()
+		file := item.fileKey()
 		if slices.Contains(seenSlugs, slug) {
 			continue
 		}
-		if !slices.Contains(slugs, slug) {
+		if file != "" && seenFiles[file] {
+			removed = append(removed, item)
+			seenSlugs = append(seenSlugs, slug)
+			continue
+		}
+		if !slices.Contains(slugs, slug) && (file == "" || !files[file]) {
 			r = append(r, item)
 		} else {
 			removed = append(removed, item)
 		}
 		seenSlugs = append(seenSlugs, slug)
+		if file != "" {
+			seenFiles[file] = true
+		}
 	}
 	return r, removed
 }
 
+// fileKeys returns the set of known file keys for the episodes.
+func (l EpisodeList) fileKeys() map[string]bool {
+	ret := map[string]bool{}
+	for _, item := range l {
+		if file := item.fileKey(); file != "" {
+			ret[file] = true
+		}
+	}
+	return ret
+}
+
+// withAbsoluteIndexes returns a copy of the list sorted by season and episode.
+// When the server provides no absolute numbering at all, regular (non-special)
+// episodes are numbered in order starting at 1.
+func (l EpisodeList) withAbsoluteIndexes() EpisodeList {
+	ret := slices.Clone(l)
+	sort.Sort(ret)
+	for _, episode := range ret {
+		if episode.AbsoluteIndex != 0 {
+			return ret
+		}
+	}
+	n := 0
+	for idx := range ret {
+		if ret[idx].Season == 0 {
+			continue
+		}
+		n++
+		ret[idx].AbsoluteIndex = n
+	}
+	return ret
+}
+
 /*
 // ids returns a list of ids for the episodes
 func (l EpisodeList) ids() []int {
@@ -203,15 +406,89 @@ func episodeWithMetadata(m Metadata) (*Episode, error) {
 		return nil, err
 	}
 	return &Episode{
-		ID:        id,
-		Title:     m.Title,
-		Show:      ShowTitle(m.GrandparentTitle),
-		Season:    SeasonNumber(m.ParentIndex),
-		ViewCount: m.ViewCount,
-		Episode:   EpisodeNumber(m.Index),
+		ID:             id,
+		Title:          m.Title,
+		Show:           ShowTitle(m.GrandparentTitle),
+		Season:         SeasonNumber(m.ParentIndex),
+		ViewCount:      m.ViewCount,
+		Episode:        EpisodeNumber(m.Index),
+		ContentRating:  m.ContentRating,
+		Year:           m.Year,
+		AudienceRating: m.AudienceRating,
+		Labels:         tagNames(m.Label),
+		AbsoluteIndex:  m.AbsoluteIndex,
+		Media:          episodeMediaWithMetadata(m),
 	}, nil
 }
 
+// episodeMediaWithVideo converts the media versions and parts of a video.
+func episodeMediaWithVideo(item Video) ([]EpisodeMedia, error) {
+	ret := make([]EpisodeMedia, len(item.Media))
+	for idx, m := range item.Media {
+		id, err := intFromString(m.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error converting Media ID: %w", err)
+		}
+		du, err := intFromString(m.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("error converting Media Duration: %w", err)
+		}
+		ret[idx] = EpisodeMedia{
+			ID:              id,
+			Duration:        time.Duration(du) * time.Millisecond,
+			Container:       m.Container,
+			VideoResolution: m.VideoResolution,
+			Parts:           make([]MediaPart, len(m.Part)),
+		}
+		for pidx, part := range m.Part {
+			partID, err := intFromString(part.ID)
+			if err != nil {
+				return nil, fmt.Errorf("error converting Part ID: %w", err)
+			}
+			partDu, err := intFromString(part.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("error converting Part Duration: %w", err)
+			}
+			var size int64
+			if part.Size != "" {
+				if size, err = strconv.ParseInt(part.Size, 10, 64); err != nil {
+					return nil, fmt.Errorf("error converting Part Size: %w", err)
+				}
+			}
+			ret[idx].Parts[pidx] = MediaPart{
+				ID:       partID,
+				File:     part.File,
+				Size:     size,
+				Duration: time.Duration(partDu) * time.Millisecond,
+			}
+		}
+	}
+	return ret, nil
+}
+
+// episodeMediaWithMetadata converts the media versions and parts of metadata.
+func episodeMediaWithMetadata(m Metadata) []EpisodeMedia {
+	ret := make([]EpisodeMedia, len(m.Media))
+	for idx, media := range m.Media {
+		ret[idx] = EpisodeMedia{
+			ID:              media.ID,
+			Duration:        time.Duration(media.Duration) * time.Millisecond,
+			Container:       media.Container,
+			VideoResolution: media.VideoResolution,
+			Parts:           make([]MediaPart, len(media.Part)),
+		}
+		for pidx, part := range media.Part {
+			ret[idx].Parts[pidx] = MediaPart{
+				ID:       part.ID,
+				File:     part.File,
+				Size:     int64(part.Size),
+				Duration: time.Duration(part.Duration) * time.Millisecond,
+			}
+		}
+	}
+	return ret
+}
+
 // WatchSpan returns the time between the earliest and latest watch
 func (l EpisodeList) WatchSpan() (*time.Duration, error) {
 	earliest := l.EarliestWatched()
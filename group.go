@@ -0,0 +1,164 @@
+package goflex
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// partTitle matches titles ending in a part marker such as "(1)", "(Part 2)",
+// "Part Two" or "Pt. 3", capturing the title before the marker.
+var partTitle = regexp.MustCompile(`(?i)^(.*?)[\s,:;-]*(?:\(\s*(?:part\s+|pt\.?\s*)?(\w+)\s*\)|\b(?:part|pt\.?)\s*(\w+))\s*$`)
+
+// maxTitlePart is the highest numeric part marker recognized in titles, so
+// years like "(2005)" aren't mistaken for parts.
+const maxTitlePart = 10
+
+// partWords are the non-numeric part markers recognized in titles.
+var partWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"i": 1, "ii": 2, "iii": 3, "iv": 4, "v": 5,
+	"vi": 6, "vii": 7, "viii": 8, "ix": 9, "x": 10,
+}
+
+// titlePart splits a title like "The Return (2)" into its base title and part
+// number. ok is false when the title has no part marker.
+func titlePart(title string) (base string, part int, ok bool) {
+	m := partTitle.FindStringSubmatch(title)
+	if m == nil {
+		return "", 0, false
+	}
+	marker := strings.ToLower(m[2] + m[3])
+	if n, err := strconv.Atoi(marker); err == nil {
+		part = n
+	} else if part, ok = partWords[marker]; !ok {
+		return "", 0, false
+	}
+	base = strings.TrimSpace(m[1])
+	if base == "" || part < 1 || part > maxTitlePart {
+		return "", 0, false
+	}
+	return base, part, true
+}
+
+// GroupLinked splits the list into units that must stay together when
+// shuffled. Episodes matching the same linked selector form one unit, and with
+// autoLink, so do runs of consecutive episodes of a show sharing a title apart
+// from a part marker, numbered one after another. Every other episode is a
+// unit of its own. Units are returned in air order, and episodes within a unit
+// are in air order.
+func (l EpisodeList) GroupLinked(linked EpisodeSelectorList, autoLink bool) []EpisodeList {
+	sorted := slices.Clone(l)
+	sort.Stable(sorted)
+
+	ret := []EpisodeList{}
+	units := map[string]int{}
+	for idx, episode := range sorted {
+		key := fmt.Sprintf("episode:%v", idx)
+		if i := slices.IndexFunc(linked, func(s EpisodeSelector) bool { return s.Matches(episode) }); i >= 0 {
+			key = fmt.Sprintf("linked:%v:%v", episode.Show, i)
+		} else if base, part, ok := titlePart(episode.Title); ok && autoLink {
+			key = fmt.Sprintf("auto:%v:%v", episode.Show, strings.ToLower(base))
+			// A repeated title further on in the show starts a unit of its own
+			if unit, ok := units[key]; ok && !continuesParts(ret[unit][len(ret[unit])-1], episode, part) {
+				delete(units, key)
+			}
+		}
+		if unit, ok := units[key]; ok {
+			ret[unit] = append(ret[unit], episode)
+			continue
+		}
+		units[key] = len(ret)
+		ret = append(ret, EpisodeList{episode})
+	}
+	return ret
+}
+
+// continuesParts returns true if next is the part after prev, airing straight
+// after it in the same season or first in the following one.
+func continuesParts(prev, next Episode, part int) bool {
+	_, prevPart, _ := titlePart(prev.Title)
+	if part != prevPart+1 {
+		return false
+	}
+	switch next.Season {
+	case prev.Season:
+		return next.Episode == prev.Episode+1
+	case prev.Season + 1:
+		return next.Episode == 1
+	default:
+		return false
+	}
+}
+
+// shuffleUnits shuffles the units, or any other slice, in place.
+func shuffleUnits[T any](r *rand.Rand, units []T) {
+	r.Shuffle(len(units), func(i, j int) {
+		units[i], units[j] = units[j], units[i]
+	})
+}
+
+// flattenUnits joins the units back into a single list.
+func flattenUnits(units []EpisodeList) EpisodeList {
+	ret := EpisodeList{}
+	for _, unit := range units {
+		ret = append(ret, unit...)
+	}
+	return ret
+}
+
+// limitUnits joins the units into a single list, stopping once its runtime
+// reaches target. Units that would take it past maxItems episodes are left
+// out, so a unit is never cut in two. Zero values mean no limit.
+func limitUnits(units []EpisodeList, target time.Duration, maxItems int) EpisodeList {
+	ret := EpisodeList{}
+	var runtime time.Duration
+	for _, unit := range units {
+		if target > 0 && runtime >= target {
+			break
+		}
+		if maxItems > 0 && len(ret)+len(unit) > maxItems {
+			continue
+		}
+		ret = append(ret, unit...)
+		runtime += unit.Runtime()
+	}
+	return ret
+}
+
+// interleaveWeighted merges the units of several series into one list, so that
+// at any point in the list each series has contributed episodes in proportion
+// to its weight. Series that run out drop out, and the rest fill the remainder.
+func interleaveWeighted(series [][]EpisodeList, weights []float64) []EpisodeList {
+	ret := []EpisodeList{}
+	next := make([]int, len(series))
+	placed := make([]int, len(series))
+	for {
+		pick := -1
+		var best float64
+		for idx, units := range series {
+			if next[idx] >= len(units) || weights[idx] <= 0 {
+				continue
+			}
+			// Pick the series furthest behind its share, counting the
+			// episode about to be placed as half placed to spread ties.
+			score := (float64(placed[idx]) + 0.5) / weights[idx]
+			if pick == -1 || score < best {
+				pick, best = idx, score
+			}
+		}
+		if pick == -1 {
+			return ret
+		}
+		unit := series[pick][next[pick]]
+		ret = append(ret, unit)
+		placed[pick] += len(unit)
+		next[pick]++
+	}
+}
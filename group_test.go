@@ -0,0 +1,185 @@
+package goflex
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTitlePart(t *testing.T) {
+	tests := map[string]struct {
+		base string
+		part int
+		ok   bool
+	}{
+		"The Return (1)":              {"The Return", 1, true},
+		"The Return (Part 2)":         {"The Return", 2, true},
+		"Best of Both Worlds Part II": {"Best of Both Worlds", 2, true},
+		"Chain of Command, Part One":  {"Chain of Command", 1, true},
+		"Homecoming: Pt. 3":           {"Homecoming", 3, true},
+		"Pilot":                       {"", 0, false},
+		"Part Time Pals":              {"", 0, false},
+		"Area 51 (Extended)":          {"", 0, false},
+		"Batman Begins (2005)":        {"", 0, false},
+		"The Reunion Part 1999":       {"", 0, false},
+		"Escape Part X":               {"Escape", 10, true},
+	}
+	for given, tt := range tests {
+		base, part, ok := titlePart(given)
+		require.Equal(t, tt.ok, ok, given)
+		require.Equal(t, tt.base, base, given)
+		require.Equal(t, tt.part, part, given)
+	}
+}
+
+func TestGroupLinked(t *testing.T) {
+	linked, err := NewEpisodeSelector("S01E05-S01E06")
+	require.NoError(t, err)
+	given := EpisodeList{
+		{Show: "foo", Title: "Finale (2)", Season: 2, Episode: 1},
+		{Show: "foo", Title: "Alone", Season: 1, Episode: 1},
+		{Show: "foo", Title: "Finale (1)", Season: 1, Episode: 9},
+		{Show: "foo", Title: "Heist", Season: 1, Episode: 6},
+		{Show: "foo", Title: "Setup", Season: 1, Episode: 5},
+		{Show: "bar", Title: "Finale (3)", Season: 1, Episode: 1},
+	}
+
+	titles := func(units []EpisodeList) [][]string {
+		ret := [][]string{}
+		for _, unit := range units {
+			got := []string{}
+			for _, e := range unit {
+				got = append(got, e.Title)
+			}
+			ret = append(ret, got)
+		}
+		return ret
+	}
+
+	require.Equal(t, [][]string{
+		{"Alone"},
+		{"Finale (3)"},
+		{"Setup", "Heist"},
+		{"Finale (1)", "Finale (2)"},
+	}, titles(given.GroupLinked(EpisodeSelectorList{*linked}, true)))
+
+	require.Equal(t, [][]string{
+		{"Alone"},
+		{"Finale (3)"},
+		{"Setup"},
+		{"Heist"},
+		{"Finale (1)"},
+		{"Finale (2)"},
+	}, titles(given.GroupLinked(nil, false)))
+
+	// Detecting parts by title is on unless turned off
+	require.True(t, RandomizeSeries{}.autoLink())
+	require.False(t, RandomizeSeries{AutoLink: toPTR(false)}.autoLink())
+
+	units := given.GroupLinked(EpisodeSelectorList{*linked}, true)
+	shuffleUnits(rand.New(rand.NewPCG(1, 2)), units)
+	require.Len(t, flattenUnits(units), len(given))
+}
+
+func TestGroupLinkedRepeatedTitles(t *testing.T) {
+	given := EpisodeList{
+		{Show: "foo", Title: "Treehouse of Horror II", Season: 3, Episode: 7},
+		{Show: "foo", Title: "Treehouse of Horror III", Season: 4, Episode: 5},
+		{Show: "foo", Title: "Treehouse of Horror IV", Season: 5, Episode: 5},
+		{Show: "foo", Title: "Reunion (1)", Season: 1, Episode: 3},
+		{Show: "foo", Title: "Reunion (2)", Season: 1, Episode: 4},
+		{Show: "foo", Title: "Reunion (1)", Season: 6, Episode: 25},
+		{Show: "foo", Title: "Reunion (2)", Season: 7, Episode: 1},
+		{Show: "foo", Title: "Gap (1)", Season: 2, Episode: 1},
+		{Show: "foo", Title: "Gap (2)", Season: 2, Episode: 3},
+	}
+
+	// Only consecutive parts airing back to back are linked
+	got := [][]string{}
+	for _, unit := range given.GroupLinked(nil, true) {
+		slugs := []string{}
+		for _, e := range unit {
+			slugs = append(slugs, e.slug())
+		}
+		got = append(got, slugs)
+	}
+	require.Equal(t, [][]string{
+		{given[3].slug(), given[4].slug()},
+		{given[7].slug()},
+		{given[8].slug()},
+		{given[0].slug()},
+		{given[1].slug()},
+		{given[2].slug()},
+		{given[5].slug(), given[6].slug()},
+	}, got)
+}
+
+func TestInterleaveWeighted(t *testing.T) {
+	units := func(show ShowTitle, n int) []EpisodeList {
+		ret := []EpisodeList{}
+		for i := 1; i <= n; i++ {
+			ret = append(ret, EpisodeList{{Show: show, Episode: EpisodeNumber(i)}})
+		}
+		return ret
+	}
+	shows := func(units []EpisodeList) string {
+		ret := ""
+		for _, e := range flattenUnits(units) {
+			ret += string(e.Show)
+		}
+		return ret
+	}
+
+	require.Equal(t, "ababab", shows(interleaveWeighted(
+		[][]EpisodeList{units("a", 3), units("b", 3)}, []float64{1, 1},
+	)))
+	require.Equal(t, "abaabaaba", shows(interleaveWeighted(
+		[][]EpisodeList{units("a", 6), units("b", 3)}, []float64{2, 1},
+	)))
+	// Once a series runs out, the others fill the rest
+	require.Equal(t, "abaaa", shows(interleaveWeighted(
+		[][]EpisodeList{units("a", 4), units("b", 1)}, []float64{1, 1},
+	)))
+	// Linked units stay together
+	require.Equal(t, "aaba", shows(interleaveWeighted(
+		[][]EpisodeList{{{{Show: "a"}, {Show: "a"}}, {{Show: "a"}}}, units("b", 1)}, []float64{1, 1},
+	)))
+}
+
+func TestLimitUnits(t *testing.T) {
+	given := EpisodeList{
+		{Title: "one", Duration: 22 * time.Minute},
+		{Title: "two", Duration: 44 * time.Minute, ViewOffset: toPTR(4 * time.Minute)},
+		{Title: "three", Duration: 11 * time.Minute},
+		{Title: "four", Duration: 11 * time.Minute},
+	}
+	units := []EpisodeList{}
+	for _, e := range given {
+		units = append(units, EpisodeList{e})
+	}
+	require.Equal(t, 84*time.Minute, given.Runtime())
+	require.Len(t, limitUnits(units, 0, 0), 4)
+	require.Len(t, limitUnits(units, time.Hour, 0), 2)
+	require.Len(t, limitUnits(units, 62*time.Minute, 0), 2)
+	require.Len(t, limitUnits(units, 63*time.Minute, 0), 3)
+	require.Len(t, limitUnits(units, time.Hour, 1), 1)
+
+	// A cut landing inside a two-part unit leaves the whole unit out
+	twoPart := []EpisodeList{
+		{{Title: "Pilot", Duration: 22 * time.Minute}},
+		{{Title: "Return (1)", Duration: 22 * time.Minute}, {Title: "Return (2)", Duration: 22 * time.Minute}},
+		{{Title: "Heist", Duration: 22 * time.Minute}},
+	}
+	titles := func(l EpisodeList) []string {
+		ret := []string{}
+		for _, e := range l {
+			ret = append(ret, e.Title)
+		}
+		return ret
+	}
+	require.Equal(t, []string{"Pilot", "Heist"}, titles(limitUnits(twoPart, 0, 2)))
+	// A runtime target keeps the unit whole, going past the target
+	require.Equal(t, []string{"Pilot", "Return (1)", "Return (2)"}, titles(limitUnits(twoPart, 30*time.Minute, 0)))
+}
@@ -31,10 +31,20 @@ func (svc *LibraryServiceOp) List() (LibraryMap, error) {
 		if err != nil {
 			return nil, err
 		}
+		contentChangedAt, err := intFromString(libd.ContentChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error converting ContentChangedAt: %w", err)
+		}
+		scannedAt, err := optionalDateFromUnixString(libd.ScannedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error converting ScannedAt: %w", err)
+		}
 		ret[LibraryTitle(libd.Title)] = &Library{
-			ID:    id,
-			Title: libd.Title,
-			Type:  stringToLibraryType(libd.Type),
+			ID:               id,
+			Title:            libd.Title,
+			Type:             stringToLibraryType(libd.Type),
+			ContentChangedAt: contentChangedAt,
+			ScannedAt:        scannedAt,
 		}
 	}
 	return ret, nil
@@ -51,6 +61,9 @@ type Library struct {
 	ID    int
 	Title string
 	Type  LibraryType
+	// ContentChangedAt is a counter that increases whenever the library content changes
+	ContentChangedAt int
+	ScannedAt        *time.Time
 }
 
 // LibraryType is the type of library
@@ -98,7 +111,7 @@ func (svc *LibraryServiceOp) Shows(l Library) (ShowMap, error) {
 		return nil, errors.New("library is not a show library")
 	}
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/library/sections/%v/all", svc.p.baseURL, l.ID), nil)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/library/sections/%v/all?includeGuids=1", svc.p.baseURL, l.ID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -108,14 +121,11 @@ func (svc *LibraryServiceOp) Shows(l Library) (ShowMap, error) {
 	}
 	ret := ShowMap{}
 	for _, item := range sr.Directory {
-		id, err := strconv.Atoi(item.RatingKey)
+		show, err := showWithDirectory(item)
 		if err != nil {
 			return nil, err
 		}
-		ret[ShowTitle(item.Title)] = &Show{
-			ID:    id,
-			Title: ShowTitle(item.Title),
-		}
+		ret[show.Title] = show
 	}
 	return ret, nil
 }
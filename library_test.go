@@ -42,3 +42,42 @@ func TestLibraries(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 2, hits)
 }
+
+func TestLibraryShows(t *testing.T) {
+	svr := srvFile(t, "./testdata/shows.xml")
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+	got, err := p.Library.Shows(Library{ID: 2, Title: "TV Shows", Type: ShowType})
+	require.NoError(t, err)
+	require.Len(t, got, 11)
+
+	ad := got["American Dad!"]
+	require.NotNil(t, ad)
+	assert.Equal(t, 25040, ad.ID)
+	assert.Equal(t, "20th Century Fox Television", ad.Studio)
+	assert.Equal(t, "TV-14", ad.ContentRating)
+	assert.Equal(t, 2005, ad.Year)
+	assert.Equal(t, 7.0, ad.AudienceRating)
+	assert.Equal(t, []string{"Animation", "Comedy"}, ad.Genres)
+	assert.True(t, ad.HasGenre("comedy"))
+	assert.True(t, ad.HasLabel("rotation"))
+	assert.False(t, ad.HasLabel("never"))
+	assert.Equal(t, 369, ad.EpisodeCount)
+	assert.Equal(t, 355, ad.ViewedEpisodeCount)
+	assert.Equal(t, 21, ad.SeasonCount)
+	assert.Equal(t, 14, ad.UnviewedEpisodeCount())
+	assert.InDelta(t, 96.2, ad.Progress(), 0.1)
+	assert.False(t, ad.FullyWatched())
+	require.NotNil(t, ad.LastViewedAt)
+	assert.Equal(t, int64(1735881460), ad.LastViewedAt.Unix())
+
+	shows := ShowList{}
+	for _, show := range got {
+		shows = append(shows, show)
+	}
+	labeled := shows.WithLabel("Rotation")
+	require.Len(t, labeled, 1)
+	assert.EqualValues(t, "American Dad!", labeled[0].Title)
+}
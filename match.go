@@ -0,0 +1,179 @@
+package goflex
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ShowNotFoundError is returned when no show matches a requested title. It
+// carries the closest titles on the server, best match first.
+type ShowNotFoundError struct {
+	Title       ShowTitle
+	Suggestions []ShowTitle
+}
+
+// Error fulfills the error interface.
+func (e *ShowNotFoundError) Error() string {
+	return "show does not exist: " + string(e.Title)
+}
+
+// maxSuggestions is how many suggestions are returned for an unknown show.
+const maxSuggestions = 5
+
+var (
+	titleYearRE      = regexp.MustCompile(`^(.*?)\s*\((\d{4})\)$`)
+	titleQualifierRE = regexp.MustCompile(`\s*\([^)]*\)$`)
+	leadingArticles  = []string{"the ", "a ", "an "}
+	diacritics       = map[rune]string{
+		'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+		'æ': "ae", 'ç': "c", 'č': "c",
+		'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e",
+		'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+		'ñ': "n", 'ń': "n",
+		'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'œ': "oe",
+		'š': "s", 'ß': "ss",
+		'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u",
+		'ý': "y", 'ÿ': "y", 'ž': "z",
+	}
+)
+
+// splitTitleYear splits "Title (2005)" in to "Title" and 2005. Titles
+// without a year are returned as-is with a year of 0.
+func splitTitleYear(t ShowTitle) (ShowTitle, int) {
+	m := titleYearRE.FindStringSubmatch(string(t))
+	if m == nil {
+		return t, 0
+	}
+	year, err := strconv.Atoi(m[2])
+	if err != nil {
+		return t, 0
+	}
+	return ShowTitle(m[1]), year
+}
+
+// normalizeTitle folds a title down to something comparable, ignoring case,
+// punctuation, diacritics and leading articles.
+func normalizeTitle(t ShowTitle) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(string(t)) {
+		switch {
+		case r == '\'' || r == '’' || r == '‘' || r == '`':
+			continue
+		case r == '&':
+			sb.WriteString(" and ")
+		case diacritics[r] != "":
+			sb.WriteString(diacritics[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(' ')
+		}
+	}
+	ret := strings.Join(strings.Fields(sb.String()), " ")
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(ret, article) {
+			return strings.TrimPrefix(ret, article)
+		}
+	}
+	return ret
+}
+
+// baseTitle normalizes a title after dropping any trailing qualifier, such as
+// "(US)" or "(2005)".
+func baseTitle(t ShowTitle) string {
+	return normalizeTitle(ShowTitle(titleQualifierRE.ReplaceAllString(string(t), "")))
+}
+
+// matchShows returns the shows matching the given title, from the most to the
+// least strict comparison. The first comparison with any matches wins.
+func matchShows(shows ShowList, name ShowTitle) ShowList {
+	title, year := splitTitleYear(name)
+	normalized := normalizeTitle(name)
+	base := baseTitle(name)
+	tiers := []func(*Show) bool{
+		func(s *Show) bool { return s.Title == name },
+		func(s *Show) bool { return normalizeTitle(s.Title) == normalized },
+		func(s *Show) bool {
+			return (year != 0) && (s.Year == year) && (baseTitle(s.Title) == normalizeTitle(title))
+		},
+		func(s *Show) bool { return (year == 0) && (baseTitle(s.Title) == base) },
+	}
+	for _, tier := range tiers {
+		ret := ShowList{}
+		for _, show := range shows {
+			if tier(show) {
+				ret = append(ret, show)
+			}
+		}
+		if len(ret) > 0 {
+			return ret
+		}
+	}
+	return ShowList{}
+}
+
+// suggestShows ranks show titles by how close they are to the given title,
+// returning at most n unique titles that are reasonably similar.
+func suggestShows(shows ShowList, name ShowTitle, n int) []ShowTitle {
+	type scored struct {
+		title ShowTitle
+		score int
+	}
+	want := baseTitle(name)
+	seen := map[ShowTitle]bool{}
+	candidates := []scored{}
+	for _, show := range shows {
+		if seen[show.Title] {
+			continue
+		}
+		seen[show.Title] = true
+		got := baseTitle(show.Title)
+		score := levenshtein(want, got)
+		if (want != "") && (strings.Contains(got, want) || strings.Contains(want, got)) {
+			score = min(score, 1)
+		}
+		if score > max(3, len([]rune(want))/3) {
+			continue
+		}
+		candidates = append(candidates, scored{title: show.Title, score: score})
+	}
+	slices.SortFunc(candidates, func(a, b scored) int {
+		if a.score != b.score {
+			return a.score - b.score
+		}
+		return strings.Compare(string(a.title), string(b.title))
+	})
+	ret := []ShowTitle{}
+	for _, c := range candidates {
+		if len(ret) == n {
+			break
+		}
+		ret = append(ret, c.title)
+	}
+	return ret
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
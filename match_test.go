@@ -0,0 +1,102 @@
+package goflex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := map[ShowTitle]string{
+		"The Office":                "office",
+		"Bob’s Burgers":             "bobs burgers",
+		"Bob's Burgers":             "bobs burgers",
+		"Pokémon":                   "pokemon",
+		"Law & Order: SVU":          "law and order svu",
+		"  A   Different   World  ": "different world",
+		"American Dad!":             "american dad",
+	}
+	for given, expect := range tests {
+		assert.Equal(t, expect, normalizeTitle(given), given)
+	}
+}
+
+func TestSplitTitleYear(t *testing.T) {
+	title, year := splitTitleYear("Doctor Who (2005)")
+	assert.EqualValues(t, "Doctor Who", title)
+	assert.Equal(t, 2005, year)
+
+	title, year = splitTitleYear("The Office (US)")
+	assert.EqualValues(t, "The Office (US)", title)
+	assert.Equal(t, 0, year)
+}
+
+func TestMatchShowsFuzzy(t *testing.T) {
+	shows := ShowList{
+		{ID: 1, Title: "The Office (US)", Year: 2005},
+		{ID: 2, Title: "Bob's Burgers", Year: 2011},
+		{ID: 3, Title: "Doctor Who", Year: 1963},
+		{ID: 4, Title: "Doctor Who", Year: 2005},
+		{ID: 5, Title: "Pokémon", Year: 1997},
+	}
+	tests := map[ShowTitle][]int{
+		"The Office (US)":   {1},
+		"the office":        {1},
+		"Office":            {1},
+		"Bob’s Burgers":     {2},
+		"bobs burgers":      {2},
+		"Doctor Who":        {3, 4},
+		"Doctor Who (2005)": {4},
+		"Doctor Who (1999)": {},
+		"pokemon":           {5},
+		"Never Exists":      {},
+	}
+	for given, expect := range tests {
+		got := matchShows(shows, given)
+		ids := []int{}
+		for _, show := range got {
+			ids = append(ids, show.ID)
+		}
+		assert.Equal(t, expect, ids, given)
+	}
+}
+
+func TestSuggestShows(t *testing.T) {
+	shows := ShowList{
+		{Title: "American Dad!"},
+		{Title: "American Dad!"},
+		{Title: "Aqua Teen Hunger Force"},
+		{Title: "King of the Hill"},
+	}
+	assert.Equal(t, []ShowTitle{"American Dad!"}, suggestShows(shows, "Amercan Dad", 5))
+	assert.Equal(t, []ShowTitle{"Aqua Teen Hunger Force"}, suggestShows(shows, "aqua teen", 5))
+	assert.Empty(t, suggestShows(shows, "Completely Different", 5))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("same", "same"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "four"))
+}
+
+func TestStrictMatchSuggestions(t *testing.T) {
+	svr := showsServer(t)
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	_, err = p.Shows.StrictMatch("Amercan Dad")
+	require.Error(t, err)
+	var notFound *ShowNotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.EqualValues(t, "Amercan Dad", notFound.Title)
+	assert.Equal(t, []ShowTitle{"American Dad!"}, notFound.Suggestions)
+	assert.EqualError(t, err, "show does not exist: Amercan Dad")
+
+	got, err := p.Shows.StrictMatch("american dad")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+}
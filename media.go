@@ -1,8 +1,13 @@
 package goflex
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 )
 
 // MediaService describes the media endpoints
@@ -11,6 +16,11 @@ type MediaService interface {
 	MarkUnWatched(int) error
 	MarkEpisodeWatched(ShowTitle, SeasonNumber, EpisodeNumber) error
 	MarkEpisodeUnWatched(ShowTitle, SeasonNumber, EpisodeNumber) error
+	Metadata(int) (*Metadata, error)
+	Labels(int) ([]string, error)
+	AddLabels(int, ...string) error
+	RemoveLabels(int, ...string) error
+	EditMetadata(int, MetadataEdit) error
 }
 
 // MediaServiceOp is the operator for the MediaService
@@ -61,3 +71,182 @@ func (svc *MediaServiceOp) MarkEpisodeUnWatched(show ShowTitle, season SeasonNum
 	}
 	return svc.MarkUnWatched(key)
 }
+
+type metadataResponse struct {
+	MediaContainer struct {
+		Size     int        `json:"size"`
+		Metadata []Metadata `json:"Metadata"`
+	} `json:"MediaContainer"`
+}
+
+// Metadata returns the full metadata for a given rating key.
+func (svc *MediaServiceOp) Metadata(key int) (*Metadata, error) {
+	var res metadataResponse
+	if err := svc.p.sendRequestJSON(
+		mustNewRequest(http.MethodGet, fmt.Sprintf("%v/library/metadata/%v", svc.p.baseURL, key)),
+		&res,
+		nil,
+	); err != nil {
+		return nil, err
+	}
+	if len(res.MediaContainer.Metadata) == 0 {
+		return nil, fmt.Errorf("no metadata found for key: %v", key)
+	}
+	return &res.MediaContainer.Metadata[0], nil
+}
+
+// Labels returns the labels currently set on a piece of media.
+func (svc *MediaServiceOp) Labels(key int) ([]string, error) {
+	m, err := svc.Metadata(key)
+	if err != nil {
+		return nil, err
+	}
+	return tagNames(m.Label), nil
+}
+
+// AddLabels adds labels to a piece of media, keeping any labels already set.
+func (svc *MediaServiceOp) AddLabels(key int, labels ...string) error {
+	if len(labels) == 0 {
+		return errors.New("must specify at least one label")
+	}
+	existing, err := svc.Labels(key)
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		if !containsFold(existing, label) {
+			existing = append(existing, label)
+		}
+	}
+	v := url.Values{}
+	for idx, label := range existing {
+		v.Set(fmt.Sprintf("label[%v].tag.tag", idx), label)
+	}
+	v.Set("label.locked", "1")
+	return svc.edit(key, v)
+}
+
+// RemoveLabels removes labels from a piece of media.
+func (svc *MediaServiceOp) RemoveLabels(key int, labels ...string) error {
+	if len(labels) == 0 {
+		return errors.New("must specify at least one label")
+	}
+	// Labels are escaped once here rather than by url.Values, so a comma
+	// inside a label isn't taken as a separator
+	escaped := make([]string, len(labels))
+	for idx, label := range labels {
+		escaped[idx] = url.QueryEscape(label)
+	}
+	v := url.Values{}
+	v.Set("label.locked", "1")
+	return svc.editQuery(key, v.Encode()+"&"+url.QueryEscape("label[].tag.tag-")+"="+strings.Join(escaped, ","))
+}
+
+// edit sends a metadata edit for the given rating key.
+func (svc *MediaServiceOp) edit(key int, v url.Values) error {
+	return svc.editQuery(key, v.Encode())
+}
+
+// editQuery sends a metadata edit with an already encoded query.
+func (svc *MediaServiceOp) editQuery(key int, query string) error {
+	var ret struct{}
+	if err := svc.p.sendRequestXML(
+		mustNewRequest(
+			http.MethodPut,
+			fmt.Sprintf("%v/library/metadata/%v?%v", svc.p.baseURL, key, query),
+		),
+		&ret,
+		nil,
+	); err != nil {
+		return err
+	}
+	svc.p.cache.DeletePrefix("shows")
+	svc.p.showIndex.invalidate()
+	return nil
+}
+
+// MetadataField is an editable metadata field on a piece of media.
+type MetadataField string
+
+const (
+	// MetadataTitle is the display title
+	MetadataTitle MetadataField = "title"
+	// MetadataTitleSort is the title used when sorting
+	MetadataTitleSort MetadataField = "titleSort"
+	// MetadataSummary is the long description
+	MetadataSummary MetadataField = "summary"
+	// MetadataOriginallyAvailableAt is the original air or release date
+	MetadataOriginallyAvailableAt MetadataField = "originallyAvailableAt"
+	// MetadataContentRating is the content rating, such as TV-14
+	MetadataContentRating MetadataField = "contentRating"
+)
+
+// MetadataFields is every field that may be edited with EditMetadata.
+var MetadataFields = []MetadataField{
+	MetadataTitle,
+	MetadataTitleSort,
+	MetadataSummary,
+	MetadataOriginallyAvailableAt,
+	MetadataContentRating,
+}
+
+// MetadataEdit describes changes to the metadata of a piece of media. Nil
+// fields are left alone. Like the web UI, every edited field is locked so
+// agent refreshes don't overwrite it, unless it is also listed in Unlock.
+type MetadataEdit struct {
+	Title                 *string
+	TitleSort             *string
+	Summary               *string
+	OriginallyAvailableAt *time.Time
+	ContentRating         *string
+	// Lock locks fields without changing their values.
+	Lock []MetadataField
+	// Unlock unlocks fields so agents may update them again.
+	Unlock []MetadataField
+}
+
+func (e MetadataEdit) values() (url.Values, error) {
+	v := url.Values{}
+	set := func(f MetadataField, val *string) {
+		if val == nil {
+			return
+		}
+		v.Set(string(f)+".value", *val)
+		v.Set(string(f)+".locked", "1")
+	}
+	set(MetadataTitle, e.Title)
+	set(MetadataTitleSort, e.TitleSort)
+	set(MetadataSummary, e.Summary)
+	set(MetadataContentRating, e.ContentRating)
+	if e.OriginallyAvailableAt != nil {
+		set(MetadataOriginallyAvailableAt, toPTR(e.OriginallyAvailableAt.Format(time.DateOnly)))
+	}
+	for _, f := range e.Lock {
+		if !slices.Contains(MetadataFields, f) {
+			return nil, fmt.Errorf("unknown metadata field: %v", f)
+		}
+		v.Set(string(f)+".locked", "1")
+	}
+	for _, f := range e.Unlock {
+		if !slices.Contains(MetadataFields, f) {
+			return nil, fmt.Errorf("unknown metadata field: %v", f)
+		}
+		if slices.Contains(e.Lock, f) {
+			return nil, fmt.Errorf("cannot both lock and unlock field: %v", f)
+		}
+		v.Set(string(f)+".locked", "0")
+	}
+	if len(v) == 0 {
+		return nil, errors.New("metadata edit has no changes")
+	}
+	return v, nil
+}
+
+// EditMetadata changes the metadata of a piece of media.
+func (svc *MediaServiceOp) EditMetadata(key int, e MetadataEdit) error {
+	v, err := e.values()
+	if err != nil {
+		return err
+	}
+	return svc.edit(key, v)
+}
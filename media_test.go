@@ -0,0 +1,85 @@
+package goflex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabels(t *testing.T) {
+	var edits []*http.Request
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"25040","title":"American Dad!","Label":[{"tag":"Rotation"}]}]}}`)
+		case http.MethodPut:
+			edits = append(edits, r)
+		}
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	got, err := p.Media.Labels(25040)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Rotation"}, got)
+
+	require.NoError(t, p.Media.AddLabels(25040, "Comfort", "rotation"))
+	require.Len(t, edits, 1)
+	assert.Equal(t, "/library/metadata/25040", edits[0].URL.Path)
+	q := edits[0].URL.Query()
+	assert.Equal(t, "Rotation", q.Get("label[0].tag.tag"))
+	assert.Equal(t, "Comfort", q.Get("label[1].tag.tag"))
+	assert.Equal(t, "", q.Get("label[2].tag.tag"))
+	assert.Equal(t, "1", q.Get("label.locked"))
+
+	require.NoError(t, p.Media.RemoveLabels(25040, "Rotation"))
+	require.Len(t, edits, 2)
+	assert.Equal(t, "Rotation", edits[1].URL.Query().Get("label[].tag.tag-"))
+
+	// Spaces and ampersands are sent escaped once
+	require.NoError(t, p.Media.RemoveLabels(25040, "Saturday Morning", "Tom & Jerry"))
+	require.Len(t, edits, 3)
+	assert.Equal(t, "Saturday Morning,Tom & Jerry", edits[2].URL.Query().Get("label[].tag.tag-"))
+	assert.Equal(t, "1", edits[2].URL.Query().Get("label.locked"))
+
+	require.Error(t, p.Media.AddLabels(25040))
+}
+
+func TestEditMetadata(t *testing.T) {
+	var got *http.Request
+	svr := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	aired := time.Date(2005, time.February, 6, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, p.Media.EditMetadata(2211, MetadataEdit{
+		Title:                 toPTR("The Peggy Horror Picture Show"),
+		OriginallyAvailableAt: &aired,
+		Lock:                  []MetadataField{MetadataSummary},
+		Unlock:                []MetadataField{MetadataTitleSort},
+	}))
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/library/metadata/2211", got.URL.Path)
+	q := got.URL.Query()
+	assert.Equal(t, "The Peggy Horror Picture Show", q.Get("title.value"))
+	assert.Equal(t, "1", q.Get("title.locked"))
+	assert.Equal(t, "2005-02-06", q.Get("originallyAvailableAt.value"))
+	assert.Equal(t, "1", q.Get("summary.locked"))
+	assert.Equal(t, "0", q.Get("titleSort.locked"))
+	assert.False(t, q.Has("summary.value"))
+
+	require.EqualError(t, p.Media.EditMetadata(2211, MetadataEdit{}), "metadata edit has no changes")
+	require.EqualError(t, p.Media.EditMetadata(2211, MetadataEdit{Lock: []MetadataField{"bogus"}}), "unknown metadata field: bogus")
+}
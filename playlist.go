@@ -7,8 +7,10 @@ import (
 	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -42,7 +44,26 @@ type PlaylistService interface {
 
 // PlaylistServiceOp is the operator for the PlaylistService.
 type PlaylistServiceOp struct {
-	p *Flex
+	p      *Flex
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// seed returns the seed to randomize a request with, drawing a new one from
+// the service's generator when the request doesn't set one.
+func (svc *PlaylistServiceOp) seed(req RandomizeRequest) uint64 {
+	if req.Seed != nil {
+		return *req.Seed
+	}
+	svc.randMu.Lock()
+	defer svc.randMu.Unlock()
+	return svc.rand.Uint64()
+}
+
+// newSeededRand returns a generator that always produces the same sequence
+// for a given seed.
+func newSeededRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
 }
 
 // PlaylistEpisodeCache describes the playlist episodes
@@ -67,6 +88,34 @@ func (p Playlist) cacheKey() string {
 type RandomizeSeries struct {
 	Filter       EpisodeFilter `json:"episodes" yaml:"episodes"`
 	LookbackDays int           `json:"lookback" yaml:"lookback_days"`
+	// IncludeOnly limits the series to episodes matching these selectors.
+	IncludeOnly EpisodeSelectorList `json:"include_only,omitempty" yaml:"include_only"`
+	// Exclude keeps episodes matching these selectors out of the playlist.
+	Exclude EpisodeSelectorList `json:"exclude,omitempty" yaml:"exclude"`
+	// Linked episode groups, such as S02E10-S02E11, are kept together and in
+	// order when shuffling.
+	Linked EpisodeSelectorList `json:"linked,omitempty" yaml:"linked"`
+	// AutoLink also keeps back to back episodes together whose titles differ
+	// only by a part marker, like "The Return (1)" and "The Return (2)".
+	// Defaults to true.
+	AutoLink *bool `json:"auto_link,omitempty" yaml:"auto_link"`
+	// Weight sets how much of the playlist comes from this series relative to
+	// the others. Defaults to 1. Only the shuffle strategy uses weights.
+	Weight float64 `json:"weight,omitempty" yaml:"weight"`
+}
+
+// autoLink returns whether multi-part episodes are detected by title,
+// defaulting to true when unset.
+func (s RandomizeSeries) autoLink() bool {
+	return s.AutoLink == nil || *s.AutoLink
+}
+
+// weight returns the series weight, defaulting to 1 when unset.
+func (s RandomizeSeries) weight() float64 {
+	if s.Weight == 0 {
+		return 1
+	}
+	return s.Weight
 }
 
 // RandomizeRequestOpt defines how you request a new RandomizeRequest.
@@ -80,6 +129,108 @@ type RandomizeRequest struct {
 	Playlist PlaylistTitle     `yaml:"playlist"`
 	Series   []RandomizeSeries `yaml:"series"`
 	RefillAt int               `yaml:"refill_at"`
+	// Strategy is the name of the Strategy ordering the refilled playlist.
+	// Defaults to shuffle.
+	Strategy string `yaml:"strategy"`
+	// Seed makes the refill order reproducible. When unset a new seed is
+	// picked for every refill and recorded in the response.
+	Seed *uint64 `yaml:"seed"`
+	// RefillBelow refills the playlist once the runtime left in it drops
+	// below this duration, in addition to RefillAt.
+	RefillBelow time.Duration `yaml:"refill_below"`
+	// TargetLength limits a refill to about this much runtime. The episode,
+	// or linked episodes, crossing the target are still added.
+	TargetLength time.Duration `yaml:"target_length"`
+	// MaxItems limits a refill to this many episodes. Linked episodes that
+	// don't fit together are left out rather than split.
+	MaxItems int `yaml:"max_items"`
+	// Mode decides whether a refill replaces the playlist or tops it up.
+	// Defaults to replace.
+	Mode RefillMode `yaml:"mode"`
+	// DryRun plans the randomize without changing anything on the server.
+	// The plan is returned in the RandomizeResponse.
+	DryRun bool `yaml:"dry_run"`
+}
+
+// WithDryRun plans the randomize without changing the playlist.
+func WithDryRun() RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.DryRun = true
+	}
+}
+
+// RefillMode describes how a playlist is refilled.
+type RefillMode string
+
+const (
+	// RefillReplace clears the playlist and fills it with a new order.
+	RefillReplace RefillMode = "replace"
+	// RefillTopUp keeps the remaining items in place and appends new episodes
+	// until the playlist reaches its target length or item count.
+	RefillTopUp RefillMode = "top-up"
+)
+
+// WithMode sets how the playlist is refilled.
+func WithMode(m RefillMode) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.Mode = m
+	}
+}
+
+// refillLimits returns the runtime and item limits for new episodes given the
+// episodes still in the playlist. When topping up, the remaining items count
+// against the limits, and ok is false if they already meet one.
+func (req RandomizeRequest) refillLimits(remaining EpisodeList) (target time.Duration, maxItems int, ok bool) {
+	target, maxItems = req.TargetLength, req.MaxItems
+	if req.Mode != RefillTopUp {
+		return target, maxItems, true
+	}
+	if target > 0 {
+		if target -= remaining.Runtime(); target <= 0 {
+			return 0, 0, false
+		}
+	}
+	if maxItems > 0 {
+		if maxItems -= len(remaining); maxItems <= 0 {
+			return 0, 0, false
+		}
+	}
+	return target, maxItems, true
+}
+
+// WithRefillBelow refills the playlist when its remaining runtime drops below d.
+func WithRefillBelow(d time.Duration) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.RefillBelow = d
+	}
+}
+
+// WithTargetLength limits refills to about d of runtime.
+func WithTargetLength(d time.Duration) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.TargetLength = d
+	}
+}
+
+// WithMaxItems limits refills to n episodes.
+func WithMaxItems(n int) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.MaxItems = n
+	}
+}
+
+// WithSeed sets the seed used to randomize the playlist.
+func WithSeed(seed uint64) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.Seed = &seed
+	}
+}
+
+// WithStrategy sets the name of the ordering strategy.
+func WithStrategy(name string) RandomizeRequestOpt {
+	return func(r *RandomizeRequest) {
+		r.Strategy = name
+	}
 }
 
 // NewRandomizeRequest returns a new RandomizeRequest using functional options
@@ -96,13 +247,90 @@ func NewRandomizeRequest(
 	for _, opt := range opts {
 		opt(&req)
 	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Validate returns an error if the request can't be randomized.
+func (req RandomizeRequest) Validate() error {
 	if req.Playlist == "" {
-		return nil, errors.New("playlist must not be empty")
+		return atField(errors.New("playlist must not be empty"), "playlist")
 	}
 	if len(req.Series) == 0 {
-		return nil, errors.New("series muset not be empty")
+		return atField(errors.New("series muset not be empty"), "series")
 	}
-	return &req, nil
+	if _, err := StrategyWithName(req.Strategy); err != nil {
+		return atField(err, "strategy")
+	}
+	if slices.Contains(unweightedStrategies, req.Strategy) {
+		for idx, series := range req.Series {
+			if series.Weight != 0 {
+				return atField(fmt.Errorf("weight is only used by the %v strategy, not %v", StrategyShuffle, req.Strategy), "series", idx, "weight")
+			}
+		}
+	}
+	for _, limit := range []struct {
+		key   string
+		value int64
+	}{
+		{"refill_below", int64(req.RefillBelow)},
+		{"target_length", int64(req.TargetLength)},
+		{"max_items", int64(req.MaxItems)},
+	} {
+		if limit.value < 0 {
+			return atField(errors.New("refill_below, target_length and max_items must not be negative"), limit.key)
+		}
+	}
+	if (req.TargetLength > 0) && (req.TargetLength < req.RefillBelow) {
+		return atField(errors.New("target_length must not be less than refill_below"), "target_length")
+	}
+	switch req.Mode {
+	case "", RefillReplace, RefillTopUp:
+	default:
+		return atField(fmt.Errorf("unknown refill mode: %v", req.Mode), "mode")
+	}
+	if (req.MaxItems > 0) && (req.MaxItems <= req.RefillAt) {
+		return atField(errors.New("max_items must be greater than refill_at"), "max_items")
+	}
+	for idx, series := range req.Series {
+		if (series.Filter.Show == "") && (series.Filter.GUID == "") {
+			return atField(errors.New("series must have a show or guid"), "series", idx, "episodes")
+		}
+		if err := series.Filter.Specials.validate(); err != nil {
+			return atField(err, "series", idx, "episodes", "specials")
+		}
+		if (series.Filter.LatestSeason != 0) && (series.Filter.EarliestSeason > series.Filter.LatestSeason) {
+			return atField(errors.New("earliest_season must not be after latest_season"), "series", idx, "episodes", "earliest_season")
+		}
+		if series.Weight < 0 {
+			return atField(errors.New("series weight must not be negative"), "series", idx, "weight")
+		}
+	}
+	return nil
+}
+
+// fieldError is an error caused by a single setting of a request, found by
+// the path of yaml keys and series indexes leading to it.
+type fieldError struct {
+	path []any
+	err  error
+}
+
+// atField returns err as caused by the setting at path.
+func atField(err error, path ...any) error {
+	return &fieldError{path: path, err: err}
+}
+
+// Error fulfills the error interface.
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *fieldError) Unwrap() error {
+	return e.err
 }
 
 // RandomizeResponse is what we get back from requesting a Playlist be randomized.
@@ -114,6 +342,16 @@ type RandomizeResponse struct {
 	OriginalEpisodes EpisodeList   `json:"original_episodes,omitempty"`
 	UnviewedEpisodes EpisodeList   `json:"unviewed_episodes,omitempty"`
 	SleepFor         time.Duration `json:"next_check,omitempty"`
+	// Seed is the seed the refill was randomized with. Requesting it again
+	// replays the refill exactly.
+	Seed uint64 `json:"seed"`
+	// Refilled is true when episodes were inserted, or would be with a dry
+	// run. A refill reason alone doesn't mean anything was added.
+	Refilled bool `json:"refilled,omitempty"`
+	// DryRun is true when nothing was changed on the server.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Planned is the playlist as it would be after a dry run.
+	Planned EpisodeList `json:"planned,omitempty"`
 }
 
 func (svc *PlaylistServiceOp) processCreation(resp *RandomizeResponse, playlist *Playlist) error {
@@ -135,18 +373,15 @@ func (svc *PlaylistServiceOp) processCreation(resp *RandomizeResponse, playlist
 func (svc *PlaylistServiceOp) processViewed(
 	resp *RandomizeResponse,
 	req RandomizeRequest,
-) (map[ShowTitle]EpisodeList, error) {
-	viewedMap := make(map[ShowTitle]EpisodeList, len(req.Series))
+) (map[int]EpisodeList, error) {
+	viewedMap := make(map[int]EpisodeList, len(req.Series))
+	allViewed := EpisodeList{}
 
-	// collect the total removed and remaining in all of the series below
-	for _, series := range req.Series {
-		exists, err := svc.p.Shows.Exists(series.Filter.Show)
+	for idx, series := range req.Series {
+		shows, err := svc.p.Shows.Resolve(series.Filter)
 		if err != nil {
 			return nil, err
 		}
-		if !exists {
-			return nil, errors.New("show does not exist: " + string(series.Filter.Show))
-		}
 
 		// Get viewed
 		since := -daysToDuration(series.LookbackDays)
@@ -157,36 +392,54 @@ func (svc *PlaylistServiceOp) processViewed(
 			"since",
 			since,
 			"show",
-			series.Filter.Show,
+			series.Filter,
 		)
-		viewedMap[series.Filter.Show], err = svc.p.Sessions.HistoryEpisodes(
+		viewed, err := svc.p.Sessions.HistoryEpisodes(
 			time.Now().Add(since),
-			series.Filter.Show,
+			shows.Titles()...,
 		)
 		if err != nil {
 			return nil, err
 		}
-		svc.p.logger.Debug("found viewed episodes", "count", len(viewedMap[series.Filter.Show]))
-
-		// Figure out remaining
-		remaining, removed := resp.OriginalEpisodes.Subtract(viewedMap[series.Filter.Show])
-		svc.p.logger.Debug(
-			"removed viewed episodes",
-			"removed",
-			len(removed),
-			"remaining",
-			len(remaining),
-			"show",
-			series.Filter.Show,
-		)
-		resp.Remaining = append(resp.Remaining, remaining...)
-		resp.Removed = append(resp.Removed, removed...)
-	}
+		// Titles aren't unique across libraries, so only keep history of the
+		// shows the series resolved to
+		viewedMap[idx] = viewed.OfShows(shows)
+		svc.p.logger.Debug("found viewed episodes", "count", len(viewedMap[idx]))
+
+		allViewed = append(allViewed, viewedMap[idx]...)
+	}
+
+	// Figure out remaining once, against everything viewed in any series, so
+	// each playlist item is counted a single time
+	resp.Remaining, resp.Removed = resp.OriginalEpisodes.Subtract(allViewed)
+	svc.p.logger.Debug(
+		"removed viewed episodes",
+		"removed",
+		len(resp.Removed),
+		"remaining",
+		len(resp.Remaining),
+	)
 	return viewedMap, nil
 }
 
 func (svc *PlaylistServiceOp) initRandomize(req RandomizeRequest) (*RandomizeResponse, *Playlist, error) {
-	resp := &RandomizeResponse{}
+	resp := &RandomizeResponse{DryRun: req.DryRun}
+	if req.DryRun {
+		// Plan against the existing playlist, or an empty one we would create
+		exists, err := svc.Exists(req.Playlist)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			resp.Created = true
+			return resp, &Playlist{Title: req.Playlist}, nil
+		}
+		playlist, err := svc.GetWithName(req.Playlist)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, playlist, nil
+	}
 	// Inspect the playlist, create it if it doesn't exist
 	playlist, created, err := svc.p.Playlists.GetOrCreate(req.Playlist, VideoPlaylist, false)
 	if err != nil {
@@ -219,11 +472,12 @@ func (svc *PlaylistServiceOp) Randomize(req RandomizeRequest) (*RandomizeRespons
 	}
 
 	// Did we dip below the refill line?
-	// DREW: Figure out the runtime and base refill on that
-	// svc.p.logger.Info("remain", "remaining", resp.Remaining.Runtime())
 	if (resp.RefillReason == "") && len(resp.Remaining) <= req.RefillAt {
 		resp.RefillReason = fmt.Sprintf("playlist dipped below %v, was at: %v", req.RefillAt, len(resp.Remaining))
 	}
+	if runtime := resp.Remaining.Runtime(); (resp.RefillReason == "") && (runtime < req.RefillBelow) {
+		resp.RefillReason = fmt.Sprintf("playlist runtime dipped below %v, was at: %v", req.RefillBelow, runtime)
+	}
 
 	// Remove all the stuff we have already seen
 	if err := svc.removeSeen(resp, req, *playlist); err != nil {
@@ -238,7 +492,20 @@ func (svc *PlaylistServiceOp) Randomize(req RandomizeRequest) (*RandomizeRespons
 	}
 
 	// Figure out when we should check again
-	if resp.SleepFor, err = svc.sleepFor(*playlist); err != nil {
+	if req.DryRun {
+		resp.Planned = resp.Remaining
+		if resp.RefillReason != "" {
+			if req.Mode == RefillTopUp {
+				resp.Planned = append(slices.Clone(resp.Remaining), resp.UnviewedEpisodes...)
+			} else {
+				resp.Planned = resp.UnviewedEpisodes
+			}
+		}
+		resp.SleepFor, err = svc.sleepForEpisodes(*playlist, resp.Planned)
+	} else {
+		resp.SleepFor, err = svc.sleepFor(*playlist)
+	}
+	if err != nil {
 		slog.Warn("error finding sleep for playlist", "playlist", playlist.Title, "error", err)
 	}
 	return resp, nil
@@ -249,6 +516,12 @@ func (svc *PlaylistServiceOp) sleepFor(playlist Playlist) (time.Duration, error)
 	if err != nil {
 		return svc.p.maxSleep, fmt.Errorf("error fetching episodes: %w", err)
 	}
+	return svc.sleepForEpisodes(playlist, episodes)
+}
+
+// sleepForEpisodes returns how long to wait before checking a playlist holding
+// the given episodes again.
+func (svc *PlaylistServiceOp) sleepForEpisodes(playlist Playlist, episodes EpisodeList) (time.Duration, error) {
 	if len(episodes) == 0 {
 		svc.p.logger.Debug("no episodes in playlist, sleeping for max", "playlist", playlist.Title)
 		return svc.p.maxSleep, nil
@@ -282,44 +555,75 @@ func (svc *PlaylistServiceOp) refillRand(
 	resp *RandomizeResponse,
 	req RandomizeRequest,
 	playlist Playlist,
-	viewedMap map[ShowTitle]EpisodeList,
+	viewedMap map[int]EpisodeList,
 ) error {
-	svc.p.logger.Debug("attempting to refill playlist", "playlist", req.Playlist, "reason", resp.RefillReason)
-	if err := svc.Clear(playlist); err != nil {
+	resp.Seed = svc.seed(req)
+	svc.p.logger.Debug("attempting to refill playlist", "playlist", req.Playlist, "reason", resp.RefillReason, "seed", resp.Seed, "mode", req.Mode)
+	target, maxItems, ok := req.refillLimits(resp.Remaining)
+	if !ok {
+		svc.p.logger.Info("playlist already at target, nothing to top up", "title", playlist.Title)
+		return nil
+	}
+	strategy, err := StrategyWithName(req.Strategy)
+	if err != nil {
 		return err
 	}
-	for _, series := range req.Series {
-		shows, err := svc.p.Shows.Match(series.Filter.Show)
+	candidates := make([]SeriesEpisodes, 0, len(req.Series))
+	for idx, series := range req.Series {
+		shows, err := svc.p.Shows.Resolve(series.Filter)
 		if err != nil {
 			return err
 		}
 
-		// allEpisodes, err := shows.EpisodesWithFilter(EpisodeFilter{
 		allEpisodes, err := svc.p.Shows.EpisodesWithFilter(shows, EpisodeFilter{
 			LatestSeason:   series.Filter.LatestSeason,
 			EarliestSeason: series.Filter.EarliestSeason,
+			Specials:       series.Filter.Specials,
 		})
 		if err != nil {
 			return err
 		}
 
-		unviewedEpisodes, _ := allEpisodes.Subtract(viewedMap[series.Filter.Show])
-		rand.Shuffle(len(unviewedEpisodes), func(i, j int) {
-			unviewedEpisodes[i], unviewedEpisodes[j] = unviewedEpisodes[j], unviewedEpisodes[i]
+		unviewedEpisodes, _ := allEpisodes.Subtract(viewedMap[idx])
+		if req.Mode == RefillTopUp {
+			unviewedEpisodes, _ = unviewedEpisodes.Subtract(resp.Remaining)
+		}
+		unviewedEpisodes = unviewedEpisodes.Select(series.IncludeOnly, series.Exclude)
+		candidates = append(candidates, SeriesEpisodes{
+			Units:  unviewedEpisodes.GroupLinked(series.Linked, series.autoLink()),
+			Weight: series.weight(),
+			Viewed: viewedMap[idx],
 		})
-		resp.UnviewedEpisodes = append(resp.UnviewedEpisodes, unviewedEpisodes...)
 	}
+	resp.UnviewedEpisodes = limitUnits(strategy.Order(newSeededRand(resp.Seed), candidates), target, maxItems)
 
-	if len(resp.UnviewedEpisodes) < req.RefillAt {
+	available := len(resp.UnviewedEpisodes)
+	if req.Mode == RefillTopUp {
+		available += len(resp.Remaining)
+	}
+	if available < req.RefillAt {
 		return fmt.Errorf(
 			"not enough unwatched episodes to refill. unwatched: %v, refill-at: %v",
-			len(resp.UnviewedEpisodes),
+			available,
 			req.RefillAt,
 		)
-	} else {
-		svc.p.logger.Info("refilling playlist", "title", playlist.Title, "episodes", len(resp.UnviewedEpisodes), "reason", resp.RefillReason)
-		return svc.InsertEpisodes(playlist.ID, resp.UnviewedEpisodes)
 	}
+	if req.DryRun {
+		resp.Refilled = len(resp.UnviewedEpisodes) > 0
+		svc.p.logger.Info("would refill playlist", "title", playlist.Title, "episodes", len(resp.UnviewedEpisodes), "reason", resp.RefillReason, "seed", resp.Seed, "mode", req.Mode)
+		return nil
+	}
+	if req.Mode != RefillTopUp {
+		if err := svc.Clear(playlist); err != nil {
+			return err
+		}
+	}
+	svc.p.logger.Info("refilling playlist", "title", playlist.Title, "episodes", len(resp.UnviewedEpisodes), "reason", resp.RefillReason, "seed", resp.Seed, "mode", req.Mode)
+	if err := svc.InsertEpisodes(playlist.ID, resp.UnviewedEpisodes); err != nil {
+		return err
+	}
+	resp.Refilled = len(resp.UnviewedEpisodes) > 0
+	return nil
 }
 
 func (svc *PlaylistServiceOp) removeSeen(resp *RandomizeResponse, req RandomizeRequest, playlist Playlist) error {
@@ -331,12 +635,26 @@ func (svc *PlaylistServiceOp) removeSeen(resp *RandomizeResponse, req RandomizeR
 			"removed", len(resp.Removed),
 			"original", len(resp.OriginalEpisodes),
 		)
+		if req.DryRun {
+			for _, item := range resp.Removed {
+				svc.p.logger.Info("would remove episode", "playlist", req.Playlist, "episode", item.String())
+			}
+			return nil
+		}
+		// Removed episodes came from the playlist, so we already know their
+		// item IDs and can delete them without looking each one up again
+		keys := []int{}
 		for _, item := range resp.Removed {
 			svc.p.logger.Info("removing episode", "playlist", req.Playlist, "episode", item.String())
-			if err := svc.DeleteEpisode(playlist.Title, item.Show, item.Season, item.Episode); err != nil {
-				return err
+			if item.PlaylistItemID == 0 {
+				if err := svc.DeleteEpisode(playlist.Title, item.Show, item.Season, item.Episode); err != nil {
+					return err
+				}
+				continue
 			}
+			keys = append(keys, item.PlaylistItemID)
 		}
+		return svc.deleteItem(playlist, keys...)
 	}
 	return nil
 }
@@ -560,18 +878,45 @@ func episodeWithVideo(item Video) (*Episode, error) {
 			return nil, fmt.Errorf("error converting ViewCount: %w", err)
 		}
 	}
+	year, err := intFromString(item.Year)
+	if err != nil {
+		return nil, fmt.Errorf("error converting Year: %w", err)
+	}
+	rating, err := floatFromString(item.AudienceRating)
+	if err != nil {
+		return nil, fmt.Errorf("error converting AudienceRating: %w", err)
+	}
+	absolute, err := intFromString(item.AbsoluteIndex)
+	if err != nil {
+		return nil, fmt.Errorf("error converting AbsoluteIndex: %w", err)
+	}
+	showID, err := intFromString(item.GrandparentRatingKey)
+	if err != nil {
+		return nil, fmt.Errorf("error converting GrandparentRatingKey: %w", err)
+	}
+	media, err := episodeMediaWithVideo(item)
+	if err != nil {
+		return nil, err
+	}
 	viewed := time.Unix(viewedInt, 0)
 	e := &Episode{
 		ID:             id,
 		PlaylistItemID: playlistID,
 		Title:          item.Title,
 		Show:           ShowTitle(item.GrandparentTitle),
+		ShowID:         showID,
 		Season:         SeasonNumber(parentI),
 		Episode:        EpisodeNumber(index),
 		ViewOffset:     viewOffset,
 		Watched:        &viewed,
 		Duration:       time.Duration(du) * time.Millisecond,
 		ViewCount:      vc,
+		ContentRating:  item.ContentRating,
+		Year:           year,
+		AudienceRating: rating,
+		Labels:         tagNames(item.Label),
+		AbsoluteIndex:  absolute,
+		Media:          media,
 	}
 	if item.LastViewedAt != "" {
 		var viewedInt int64
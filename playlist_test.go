@@ -1,13 +1,16 @@
 package goflex
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -108,6 +111,14 @@ func TestNewRandomizeRequest(t *testing.T) {
 			wantErr: errors.New("playlist must not be empty"),
 			wantReq: nil,
 		},
+		{
+			name:     "Series without show or guid",
+			playlist: "MyPlaylist",
+			series: []RandomizeSeries{
+				{LookbackDays: 7},
+			},
+			wantErr: errors.New("series must have a show or guid"),
+		},
 		{
 			name:     "Empty series",
 			playlist: "MyPlaylist",
@@ -116,6 +127,41 @@ func TestNewRandomizeRequest(t *testing.T) {
 			wantErr:  errors.New("series muset not be empty"),
 			wantReq:  nil,
 		},
+		{
+			name:     "Runtime thresholds",
+			playlist: "MyPlaylist",
+			series:   []RandomizeSeries{{Filter: EpisodeFilter{Show: "Show1"}}},
+			opts:     []RandomizeRequestOpt{WithRefillBelow(2 * time.Hour), WithTargetLength(12 * time.Hour), WithMaxItems(40)},
+			wantReq: &RandomizeRequest{
+				Playlist:     "MyPlaylist",
+				Series:       []RandomizeSeries{{Filter: EpisodeFilter{Show: "Show1"}}},
+				RefillAt:     5,
+				RefillBelow:  2 * time.Hour,
+				TargetLength: 12 * time.Hour,
+				MaxItems:     40,
+			},
+		},
+		{
+			name:     "Target shorter than refill",
+			playlist: "MyPlaylist",
+			series:   []RandomizeSeries{{Filter: EpisodeFilter{Show: "Show1"}}},
+			opts:     []RandomizeRequestOpt{WithRefillBelow(2 * time.Hour), WithTargetLength(time.Hour)},
+			wantErr:  errors.New("target_length must not be less than refill_below"),
+		},
+		{
+			name:     "Max items at refill",
+			playlist: "MyPlaylist",
+			series:   []RandomizeSeries{{Filter: EpisodeFilter{Show: "Show1"}}},
+			opts:     []RandomizeRequestOpt{WithMaxItems(5)},
+			wantErr:  errors.New("max_items must be greater than refill_at"),
+		},
+		{
+			name:     "Unknown mode",
+			playlist: "MyPlaylist",
+			series:   []RandomizeSeries{{Filter: EpisodeFilter{Show: "Show1"}}},
+			opts:     []RandomizeRequestOpt{WithMode("shuffle-in")},
+			wantErr:  errors.New("unknown refill mode: shuffle-in"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -130,3 +176,228 @@ func TestNewRandomizeRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestRandomizeSeed(t *testing.T) {
+	p, err := New(WithBaseURL("http://plex.example.com"), WithToken("test-token"), WithRand(rand.New(rand.NewPCG(1, 2))))
+	require.NoError(t, err)
+	svc := p.Playlists.(*PlaylistServiceOp)
+
+	// Injected generator gives the same seeds every time
+	other, err := New(WithBaseURL("http://plex.example.com"), WithToken("test-token"), WithRand(rand.New(rand.NewPCG(1, 2))))
+	require.NoError(t, err)
+	require.Equal(t, svc.seed(RandomizeRequest{}), other.Playlists.(*PlaylistServiceOp).seed(RandomizeRequest{}))
+
+	// A zero seed is still reported
+	b, err := json.Marshal(RandomizeResponse{})
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"seed":0`)
+
+	// Request seed wins over the generator
+	req, err := NewRandomizeRequest("foo", []RandomizeSeries{{Filter: EpisodeFilter{Show: "bar"}}}, WithSeed(42))
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), svc.seed(*req))
+
+	// The same seed replays the same order
+	s, err := StrategyWithName(StrategyShuffle)
+	require.NoError(t, err)
+	require.Equal(t,
+		s.Order(newSeededRand(42), strategyFixture()),
+		s.Order(newSeededRand(42), strategyFixture()),
+	)
+	require.NotEqual(t,
+		s.Order(newSeededRand(42), strategyFixture()),
+		s.Order(newSeededRand(43), strategyFixture()),
+	)
+}
+
+func TestRefillLimits(t *testing.T) {
+	remaining := EpisodeList{
+		{Duration: time.Hour},
+		{Duration: time.Hour},
+	}
+	req := RandomizeRequest{TargetLength: 3 * time.Hour, MaxItems: 10}
+
+	target, maxItems, ok := req.refillLimits(remaining)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Hour, target)
+	require.Equal(t, 10, maxItems)
+
+	req.Mode = RefillTopUp
+	target, maxItems, ok = req.refillLimits(remaining)
+	require.True(t, ok)
+	require.Equal(t, time.Hour, target)
+	require.Equal(t, 8, maxItems)
+
+	req.TargetLength = 2 * time.Hour
+	_, _, ok = req.refillLimits(remaining)
+	require.False(t, ok)
+
+	req.TargetLength = 0
+	req.MaxItems = 2
+	_, _, ok = req.refillLimits(remaining)
+	require.False(t, ok)
+}
+
+// randomizeServer serves a read only library for planning randomizes, failing
+// the test on any request that would change the server.
+func randomizeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method, r.URL.String())
+		var f string
+		switch url := r.URL.Path; {
+		case url == "/identity":
+			fmt.Fprint(w, `<MediaContainer size="0" machineIdentifier="test-machine" />`)
+			return
+		case url == "/playlists":
+			f = "testdata/playlists.xml"
+		case url == "/playlists/26755/items":
+			f = "testdata/playlist-items.xml"
+		case url == "/status/sessions/history/all":
+			f = "testdata/history-sessions.xml"
+		case url == "/status/sessions":
+			f = "testdata/active-sessions.xml"
+		case url == "/library/sections/":
+			f = "testdata/libraries.xml"
+		case strings.HasSuffix(url, "/allLeaves"):
+			f = "testdata/episodes.xml"
+		case strings.HasSuffix(url, "/all"):
+			f = "testdata/shows.xml"
+		default:
+			t.Errorf("unexpected request: %v", r.URL)
+			return
+		}
+		expected, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		fmt.Fprint(w, string(expected))
+	}))
+}
+
+func TestRandomizeDryRun(t *testing.T) {
+	srv := randomizeServer(t)
+	defer srv.Close()
+
+	p, err := New(WithBaseURL(srv.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	req, err := NewRandomizeRequest(
+		"King of the Hill (Planned)",
+		[]RandomizeSeries{{Filter: EpisodeFilter{Show: "King of the Hill"}}},
+		WithDryRun(), WithSeed(7), WithMaxItems(10),
+	)
+	require.NoError(t, err)
+	got, err := p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.True(t, got.DryRun)
+	require.True(t, got.Created)
+	require.Equal(t, "newly created playlist", got.RefillReason)
+	require.Equal(t, uint64(7), got.Seed)
+	require.Len(t, got.UnviewedEpisodes, 10)
+	require.Equal(t, got.UnviewedEpisodes, got.Planned)
+
+	// Planning again with the same seed gives the same plan
+	again, err := p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.Equal(t, got.Planned, again.Planned)
+}
+
+// twoSeries are the series of a request refilling the existing Impractical
+// Jokers playlist, counting all of their history as viewed.
+func twoSeries() []RandomizeSeries {
+	return []RandomizeSeries{
+		{Filter: EpisodeFilter{Show: "Impractical Jokers"}, LookbackDays: 36500},
+		{Filter: EpisodeFilter{Show: "King of the Hill"}, LookbackDays: 36500},
+	}
+}
+
+func TestRandomizeTopUpTwoSeries(t *testing.T) {
+	srv := randomizeServer(t)
+	defer srv.Close()
+
+	p, err := New(WithBaseURL(srv.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	req, err := NewRandomizeRequest("Impractical Jokers (Randomized)", twoSeries(),
+		WithDryRun(), WithSeed(7), WithMode(RefillTopUp), WithMaxItems(150),
+	)
+	require.NoError(t, err)
+	req.RefillAt = 130
+	got, err := p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.False(t, got.Created)
+
+	// Every playlist item is either remaining or removed, once
+	require.Len(t, got.OriginalEpisodes, 120)
+	require.Len(t, got.Remaining, len(got.OriginalEpisodes)-len(got.Removed))
+	require.NotEmpty(t, got.RefillReason)
+
+	// Topping up adds new episodes without going past max_items
+	require.NotEmpty(t, got.UnviewedEpisodes)
+	require.LessOrEqual(t, len(got.Remaining)+len(got.UnviewedEpisodes), 150)
+	_, already := got.UnviewedEpisodes.Subtract(got.Remaining)
+	require.Empty(t, already)
+	require.True(t, got.Refilled)
+
+	// Already at max_items, the refill reason stands but nothing is added
+	req.MaxItems = len(got.Remaining)
+	got, err = p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.NotEmpty(t, got.RefillReason)
+	require.Empty(t, got.UnviewedEpisodes)
+	require.False(t, got.Refilled)
+}
+
+func TestRandomizeRefillBelowTwoSeries(t *testing.T) {
+	srv := randomizeServer(t)
+	defer srv.Close()
+
+	p, err := New(WithBaseURL(srv.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	req, err := NewRandomizeRequest("Impractical Jokers (Randomized)", twoSeries(), WithDryRun(), WithSeed(7))
+	require.NoError(t, err)
+	got, err := p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.Empty(t, got.RefillReason)
+	remaining, _ := got.OriginalEpisodes.Subtract(got.Removed)
+	runtime := remaining.Runtime()
+	require.Positive(t, runtime)
+	require.Equal(t, runtime, got.Remaining.Runtime())
+
+	// Runtime is counted once across series, so a threshold just above it fires
+	req.RefillBelow = runtime + time.Minute
+	got, err = p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("playlist runtime dipped below %v, was at: %v", req.RefillBelow, runtime), got.RefillReason)
+
+	req.RefillBelow = runtime
+	got, err = p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.Empty(t, got.RefillReason)
+}
+
+func TestRandomizeDryRunPlannedTwoSeries(t *testing.T) {
+	srv := randomizeServer(t)
+	defer srv.Close()
+
+	p, err := New(WithBaseURL(srv.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	req, err := NewRandomizeRequest("Impractical Jokers (Randomized)", twoSeries(), WithDryRun(), WithSeed(7))
+	require.NoError(t, err)
+
+	// Without a refill, the plan is the playlist less what was watched
+	got, err := p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.Empty(t, got.RefillReason)
+	remaining, _ := got.OriginalEpisodes.Subtract(got.Removed)
+	require.Equal(t, remaining, got.Planned)
+
+	// Topping up plans the kept episodes once, followed by the new ones
+	req.Mode = RefillTopUp
+	req.RefillAt = 130
+	got, err = p.Playlists.Randomize(*req)
+	require.NoError(t, err)
+	require.NotEmpty(t, got.UnviewedEpisodes)
+	require.Len(t, got.Planned, len(remaining)+len(got.UnviewedEpisodes))
+	require.Equal(t, remaining, got.Planned[:len(remaining)])
+}
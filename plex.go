@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
@@ -30,6 +31,10 @@ type Flex struct {
 	minSleep       time.Duration
 	client         *http.Client
 	cache          cache
+	showIndex      *showIndex
+	concurrency    int
+	requests       chan struct{} // holds a slot for each request in flight
+	rand           *rand.Rand
 	serverWasDown  bool // tracks if server was unreachable
 	Playlists      PlaylistService
 	Sessions       SessionService
@@ -43,12 +48,14 @@ type Flex struct {
 // New uses functional options for a new plex
 func New(opts ...func(*Flex)) (*Flex, error) {
 	p := &Flex{
-		client:    http.DefaultClient,
-		userAgent: "goflex " + version,
-		cache:     *newCache(),
-		maxSleep:  60 * time.Minute,
-		minSleep:  5 * time.Minute,
-		logger:    slog.Default(),
+		client:      http.DefaultClient,
+		userAgent:   "goflex " + version,
+		cache:       *newCache(),
+		showIndex:   newShowIndex(),
+		concurrency: defaultConcurrency,
+		maxSleep:    60 * time.Minute,
+		minSleep:    5 * time.Minute,
+		logger:      slog.Default(),
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -59,8 +66,16 @@ func New(opts ...func(*Flex)) (*Flex, error) {
 	if p.token == "" {
 		return nil, errors.New("must set token")
 	}
+	if p.concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+	p.requests = make(chan struct{}, p.concurrency)
+
+	if p.rand == nil {
+		p.rand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
 
-	p.Playlists = &PlaylistServiceOp{p: p}
+	p.Playlists = &PlaylistServiceOp{p: p, rand: p.rand}
 	p.Sessions = &SessionServiceOp{p: p}
 	p.Media = &MediaServiceOp{p: p}
 	p.Server = &ServerServiceOp{p: p}
@@ -70,6 +85,26 @@ func New(opts ...func(*Flex)) (*Flex, error) {
 	return p, nil
 }
 
+// defaultConcurrency is how many requests may be in flight at once.
+const defaultConcurrency = 4
+
+// WithConcurrency sets how many requests may be in flight at once. The limit
+// covers every request, so fetching shows and their seasons together can't
+// go over it.
+func WithConcurrency(n int) func(*Flex) {
+	return func(p *Flex) {
+		p.concurrency = n
+	}
+}
+
+// WithRand sets the random number generator used to pick seeds for
+// randomized playlists that don't request one.
+func WithRand(r *rand.Rand) func(*Flex) {
+	return func(p *Flex) {
+		p.rand = r
+	}
+}
+
 // WithFlexConfig sets the config for a new plex
 func WithFlexConfig(c FlexConfig) func(*Flex) {
 	return func(p *Flex) {
@@ -82,6 +117,9 @@ func WithFlexConfig(c FlexConfig) func(*Flex) {
 		if c.GarbageCollectionInterval != nil {
 			p.cache = *newCacheWithGC(*c.GarbageCollectionInterval)
 		}
+		if c.Concurrency != 0 {
+			p.concurrency = c.Concurrency
+		}
 	}
 }
 
@@ -142,6 +180,8 @@ func (p *Flex) sendRequestJSON(req *http.Request, v any, cc *cacheConfig) error
 }
 
 func (p *Flex) doReq(req *http.Request) ([]byte, error) {
+	p.requests <- struct{}{}
+	defer func() { <-p.requests }()
 	res, err := p.client.Do(req)
 	if err != nil {
 		p.serverWasDown = true
@@ -156,9 +196,9 @@ func (p *Flex) doReq(req *http.Request) ([]byte, error) {
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
 		var errRes errorResponse
 		if err = json.NewDecoder(res.Body).Decode(&errRes); err == nil {
-			return nil, errors.New(errRes.Message)
+			return nil, &StatusError{StatusCode: res.StatusCode, Message: errRes.Message}
 		}
-		return nil, fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+		return nil, &StatusError{StatusCode: res.StatusCode}
 	}
 	return content, nil
 }
@@ -176,6 +216,7 @@ func (p *Flex) CheckServerHealth() error {
 	if p.serverWasDown {
 		p.logger.Info("server reconnected after being down, flushing all caches")
 		p.cache.FlushAll()
+		p.showIndex.invalidate()
 		p.serverWasDown = false
 	}
 	return nil
@@ -232,6 +273,20 @@ func (p *Flex) sendRequestType(req *http.Request, v any, contentType string, cc
 	return nil
 }
 
+// StatusError is returned when the server answers with an error status code.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error fulfills the error interface.
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("unknown error, status code: %d", e.StatusCode)
+}
+
 type errorResponse struct {
 	Status    string `json:"status"`
 	ErrorType string `json:"errorType"`
@@ -246,25 +301,47 @@ func dclose(c io.Closer) {
 }
 
 func (p *Flex) episodeID(show ShowTitle, season SeasonNumber, episode EpisodeNumber) (int, error) {
-	shows, err := p.Shows.Match(show)
-	if err != nil {
-		return 0, err
-	}
-	episodes, err := p.Shows.Episodes(shows)
+	got, err := p.Shows.Episode(show, season, episode)
 	if err != nil {
 		return 0, err
 	}
-	for _, item := range episodes {
-		if (item.Season == season) && (item.Episode == episode) {
-			return item.ID, nil
-		}
-	}
-	return 0, errors.New("episode key not found")
+	return got.ID, nil
 }
 
 type FlexConfig struct {
 	URL                       string               `yaml:"url"`
 	Token                     string               `yaml:"token"`
 	GarbageCollectionInterval *time.Duration       `yaml:"gc_interval"`
+	Concurrency               int                  `yaml:"concurrency"`
 	Randomize                 RandomizeRequestList `yaml:"randomize"`
+	// Include lists files holding more randomize requests, relative to the
+	// config file.
+	Include []string `yaml:"include"`
+	// Servers are named profiles that can be used in place of url and token.
+	Servers       map[string]ServerConfig `yaml:"servers"`
+	DefaultServer string                  `yaml:"default_server"`
+}
+
+// ServerConfig is a named server profile in a FlexConfig.
+type ServerConfig struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// WithServer returns the config talking to the named server profile instead of
+// the top level url and token. An empty name uses default_server, if set.
+func (c FlexConfig) WithServer(name string) (FlexConfig, error) {
+	if name == "" {
+		name = c.DefaultServer
+	}
+	if name == "" {
+		return c, nil
+	}
+	server, ok := c.Servers[name]
+	if !ok {
+		return c, fmt.Errorf("unknown server: %v", name)
+	}
+	c.URL = server.URL
+	c.Token = server.Token
+	return c, nil
 }
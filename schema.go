@@ -0,0 +1,175 @@
+package goflex
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaURL is the JSON Schema dialect the config schema is written in.
+const schemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the durations time.ParseDuration accepts, like 2h30m.
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// schemaDescriber is implemented by config types that know their own schema.
+type schemaDescriber interface {
+	jsonSchema() map[string]any
+}
+
+// schemaExtras are added to the generated schema of config types, mostly to
+// say which keys must be set.
+var schemaExtras = map[reflect.Type]map[string]any{
+	reflect.TypeFor[RandomizeRequest](): {"required": []string{"playlist", "series"}},
+	reflect.TypeFor[RandomizeSeries]():  {"required": []string{"episodes"}},
+	reflect.TypeFor[EpisodeFilter](): {"anyOf": []map[string]any{
+		{"required": []string{"show"}},
+		{"required": []string{"guid"}},
+	}},
+}
+
+// schemaFieldOverrides replaces the generated schema for fields whose Go type
+// doesn't say enough, keyed by type and field name.
+var schemaFieldOverrides = map[reflect.Type]map[string]func() map[string]any{
+	reflect.TypeFor[RandomizeRequest](): {
+		"Strategy": func() map[string]any {
+			return map[string]any{"type": "string", "enum": Strategies()}
+		},
+	},
+	reflect.TypeFor[EpisodeFilter](): {
+		"GUID": func() map[string]any {
+			return map[string]any{
+				"type":        "string",
+				"description": "Plex or external GUID of the show, such as tvdb://71663",
+			}
+		},
+	},
+}
+
+// ConfigSchema returns a JSON Schema describing the YAML config file, generated
+// from the config types.
+func ConfigSchema() map[string]any {
+	defs := map[string]any{}
+	ret := schemaFor(reflect.TypeFor[FlexConfig](), defs)
+	ret["$schema"] = schemaURL
+	ret["title"] = "goflex config"
+	ret["$defs"] = defs
+	return ret
+}
+
+// ConfigSchemaJSON returns the config JSON Schema as indented JSON.
+func ConfigSchemaJSON() ([]byte, error) {
+	b, err := json.MarshalIndent(ConfigSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// schemaFor returns the schema for a type. Named structs other than FlexConfig
+// are added to defs and referenced.
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return schemaFor(t.Elem(), defs)
+	}
+	if d, ok := reflect.Zero(t).Interface().(schemaDescriber); ok {
+		return d.jsonSchema()
+	}
+	switch {
+	case t == reflect.TypeFor[time.Duration]():
+		return map[string]any{
+			"type":        "string",
+			"pattern":     durationPattern,
+			"description": "Duration such as 90m or 2h30m",
+		}
+	case t.Implements(reflect.TypeFor[encoding.TextUnmarshaler]()),
+		reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		if t == reflect.TypeFor[FlexConfig]() {
+			return structSchema(t, defs)
+		}
+		if _, ok := defs[t.Name()]; !ok {
+			// Reserve the name first, so self-referencing types terminate
+			defs[t.Name()] = nil
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the object schema for a struct, using its yaml keys.
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	props := map[string]any{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		if override, ok := schemaFieldOverrides[t][field.Name]; ok {
+			props[key] = override()
+			continue
+		}
+		props[key] = schemaFor(field.Type, defs)
+	}
+	ret := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	for k, v := range schemaExtras[t] {
+		ret[k] = v
+	}
+	return ret
+}
+
+// jsonSchema fulfills the schemaDescriber interface.
+func (SpecialsPolicy) jsonSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": []SpecialsPolicy{SpecialsInclude, SpecialsExclude, SpecialsOnly},
+	}
+}
+
+// jsonSchema fulfills the schemaDescriber interface.
+func (RefillMode) jsonSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": []RefillMode{RefillReplace, RefillTopUp},
+	}
+}
+
+// jsonSchema fulfills the schemaDescriber interface.
+func (EpisodeSelector) jsonSchema() map[string]any {
+	return map[string]any{
+		"type":        "string",
+		"description": "Season/episode range such as S02E05 or S03E01-S03E04, or a title regular expression",
+	}
+}
@@ -0,0 +1,40 @@
+package goflex
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	got := ConfigSchema()
+	require.Equal(t, schemaURL, got["$schema"])
+
+	b, err := json.Marshal(got)
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(b, &schema))
+	require.Equal(t, "string", schema.Properties["url"]["type"])
+	require.Equal(t, "#/$defs/RandomizeRequest", schema.Properties["randomize"]["items"].(map[string]any)["$ref"])
+	require.Equal(t, []string{"playlist", "series"}, schema.Defs["RandomizeRequest"].Required)
+	require.Equal(t, durationPattern, schema.Defs["RandomizeRequest"].Properties["refill_below"]["pattern"])
+	require.Contains(t, schema.Defs["RandomizeSeries"].Properties, "lookback_days")
+	require.Contains(t, schema.Defs["EpisodeFilter"].Properties, "earliest_season")
+	require.Equal(t, "string", schema.Defs["RandomizeSeries"].Properties["exclude"]["items"].(map[string]any)["type"])
+}
+
+func TestConfigSchemaPublished(t *testing.T) {
+	got, err := ConfigSchemaJSON()
+	require.NoError(t, err)
+	published, err := os.ReadFile("examples/goflex.schema.json")
+	require.NoError(t, err)
+	require.Equal(t, string(got), string(published), "regenerate with: goflex config schema > examples/goflex.schema.json")
+}
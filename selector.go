@@ -0,0 +1,130 @@
+package goflex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// selectorRange matches selectors like S02E05, S03E01-S03E04 and S03E01-E04.
+var selectorRange = regexp.MustCompile(`^[Ss](\d+)[Ee](\d+)(?:\s*-\s*(?:[Ss](\d+))?[Ee](\d+))?$`)
+
+// EpisodeSelector picks out episodes either by a season/episode range, such as
+// S02E05 or S03E01-S03E04, or by a case-insensitive regular expression on the
+// episode title.
+type EpisodeSelector struct {
+	raw   string
+	start seasonEpisode
+	end   seasonEpisode
+	title *regexp.Regexp
+}
+
+// seasonEpisode is a season and episode number pair, comparable in air order.
+type seasonEpisode struct {
+	season  SeasonNumber
+	episode EpisodeNumber
+}
+
+func (s seasonEpisode) before(o seasonEpisode) bool {
+	if s.season != o.season {
+		return s.season < o.season
+	}
+	return s.episode < o.episode
+}
+
+// NewEpisodeSelector parses a selector string.
+func NewEpisodeSelector(s string) (*EpisodeSelector, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("invalid episode selector: %q", s)
+	}
+	if m := selectorRange.FindStringSubmatch(s); m != nil {
+		n := make([]int, len(m))
+		for idx, v := range m[1:] {
+			if v == "" {
+				continue
+			}
+			var err error
+			if n[idx+1], err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid episode selector: %q: %w", s, err)
+			}
+		}
+		start := seasonEpisode{season: SeasonNumber(n[1]), episode: EpisodeNumber(n[2])}
+		end := start
+		if m[4] != "" {
+			end.episode = EpisodeNumber(n[4])
+			if m[3] != "" {
+				end.season = SeasonNumber(n[3])
+			}
+		}
+		if end.before(start) {
+			return nil, fmt.Errorf("invalid episode selector: %q ends before it starts", s)
+		}
+		return &EpisodeSelector{raw: s, start: start, end: end}, nil
+	}
+	title, err := regexp.Compile("(?i)" + s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid episode selector: %q: %w", s, err)
+	}
+	return &EpisodeSelector{raw: s, title: title}, nil
+}
+
+// Matches returns true if the episode is picked out by the selector.
+func (s EpisodeSelector) Matches(e Episode) bool {
+	if s.title != nil {
+		return s.title.MatchString(e.Title)
+	}
+	se := seasonEpisode{season: e.Season, episode: e.Episode}
+	return !se.before(s.start) && !s.end.before(se)
+}
+
+// String returns the selector as it was written.
+func (s EpisodeSelector) String() string {
+	return s.raw
+}
+
+// MarshalText fulfills the encoding.TextMarshaler interface.
+func (s EpisodeSelector) MarshalText() ([]byte, error) {
+	return []byte(s.raw), nil
+}
+
+// UnmarshalText fulfills the encoding.TextUnmarshaler interface, so selectors
+// can be written as plain strings in YAML and JSON.
+func (s *EpisodeSelector) UnmarshalText(text []byte) error {
+	got, err := NewEpisodeSelector(string(text))
+	if err != nil {
+		return err
+	}
+	*s = *got
+	return nil
+}
+
+// EpisodeSelectorList is multiple EpisodeSelectors.
+type EpisodeSelectorList []EpisodeSelector
+
+// Matches returns true if any selector in the list matches the episode.
+func (l EpisodeSelectorList) Matches(e Episode) bool {
+	for _, s := range l {
+		if s.Matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the episodes matching include, or all of them when include is
+// empty, with any episodes matching exclude removed.
+func (l EpisodeList) Select(include, exclude EpisodeSelectorList) EpisodeList {
+	ret := EpisodeList{}
+	for _, episode := range l {
+		if len(include) > 0 && !include.Matches(episode) {
+			continue
+		}
+		if exclude.Matches(episode) {
+			continue
+		}
+		ret = append(ret, episode)
+	}
+	return ret
+}
@@ -0,0 +1,91 @@
+package goflex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEpisodeSelector(t *testing.T) {
+	tests := map[string]struct {
+		given  string
+		match  []Episode
+		miss   []Episode
+		expErr string
+	}{
+		"single": {
+			given: "S02E05",
+			match: []Episode{{Season: 2, Episode: 5}},
+			miss:  []Episode{{Season: 2, Episode: 6}, {Season: 5, Episode: 2}},
+		},
+		"range": {
+			given: "s03e01-S03E04",
+			match: []Episode{{Season: 3, Episode: 1}, {Season: 3, Episode: 4}},
+			miss:  []Episode{{Season: 3, Episode: 5}, {Season: 2, Episode: 2}},
+		},
+		"range-across-seasons": {
+			given: "S01E10-S02E02",
+			match: []Episode{{Season: 1, Episode: 12}, {Season: 2, Episode: 1}},
+			miss:  []Episode{{Season: 1, Episode: 9}, {Season: 2, Episode: 3}},
+		},
+		"short-range": {
+			given: "S03E01-E02",
+			match: []Episode{{Season: 3, Episode: 2}},
+			miss:  []Episode{{Season: 3, Episode: 3}},
+		},
+		"title": {
+			given: "clip show|part \\d",
+			match: []Episode{{Title: "The Clip Show"}, {Title: "Finale Part 2"}},
+			miss:  []Episode{{Title: "Pilot"}},
+		},
+		"backwards": {
+			given:  "S03E04-S03E01",
+			expErr: `invalid episode selector: "S03E04-S03E01" ends before it starts`,
+		},
+		"bad-regex": {
+			given:  "(unclosed",
+			expErr: "invalid episode selector: \"(unclosed\": error parsing regexp: missing closing ): `(?i)(unclosed`",
+		},
+	}
+	for desc, tt := range tests {
+		got, err := NewEpisodeSelector(tt.given)
+		if tt.expErr != "" {
+			require.EqualError(t, err, tt.expErr, desc)
+			continue
+		}
+		require.NoError(t, err, desc)
+		for _, e := range tt.match {
+			require.True(t, got.Matches(e), "%v: %+v", desc, e)
+		}
+		for _, e := range tt.miss {
+			require.False(t, got.Matches(e), "%v: %+v", desc, e)
+		}
+	}
+}
+
+func TestEpisodeListSelect(t *testing.T) {
+	var series RandomizeSeries
+	require.NoError(t, yaml.Unmarshal([]byte(`
+episodes:
+  show: Futurama
+include_only:
+  - S01E01-S01E04
+exclude:
+  - S01E02
+  - clip show
+`), &series))
+	got := EpisodeList{
+		{Title: "Pilot", Season: 1, Episode: 1},
+		{Title: "Two", Season: 1, Episode: 2},
+		{Title: "Clip Show", Season: 1, Episode: 3},
+		{Title: "Four", Season: 1, Episode: 4},
+		{Title: "Five", Season: 1, Episode: 5},
+	}.Select(series.IncludeOnly, series.Exclude)
+	require.Equal(t, EpisodeList{
+		{Title: "Pilot", Season: 1, Episode: 1},
+		{Title: "Four", Season: 1, Episode: 4},
+	}, got)
+
+	require.Error(t, yaml.Unmarshal([]byte("exclude: ['S02E09-S02E01']"), &series))
+}
@@ -287,24 +287,17 @@ type Metadata struct {
 		BottomRight string `json:"bottomRight"`
 		BottomLeft  string `json:"bottomLeft"`
 	} `json:"UltraBlurColors"`
-	Genre []struct {
-		Tag string `json:"tag"`
-	} `json:"Genre,omitempty"`
-	Country []struct {
-		Tag string `json:"tag"`
-	} `json:"Country,omitempty"`
-	Director []struct {
-		Tag string `json:"tag"`
-	} `json:"Director,omitempty"`
-	Writer []struct {
-		Tag string `json:"tag"`
-	} `json:"Writer,omitempty"`
-	Role []struct {
-		Tag string `json:"tag"`
-	} `json:"Role,omitempty"`
+	Genre                  []Tag   `json:"Genre,omitempty"`
+	Country                []Tag   `json:"Country,omitempty"`
+	Director               []Tag   `json:"Director,omitempty"`
+	Writer                 []Tag   `json:"Writer,omitempty"`
+	Role                   []Tag   `json:"Role,omitempty"`
+	Label                  []Tag   `json:"Label,omitempty"`
+	GUIDs                  []GUID  `json:"Guid,omitempty"`
 	Slug                   string  `json:"slug,omitempty"`
 	ContentRating          string  `json:"contentRating,omitempty"`
 	Index                  int     `json:"index,omitempty"`
+	AbsoluteIndex          int     `json:"absoluteIndex,omitempty"`
 	AudienceRating         float64 `json:"audienceRating,omitempty"`
 	ViewCount              int     `json:"viewCount,omitempty"`
 	SkipCount              int     `json:"skipCount,omitempty"`
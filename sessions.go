@@ -3,6 +3,7 @@ package goflex
 import (
 	"fmt"
 	"net/http"
+	"path"
 	"slices"
 	"strconv"
 	"time"
@@ -68,10 +69,17 @@ func (svc *SessionServiceOp) historyEpisodes() (EpisodeList, error) {
 			return nil, err
 		}
 
+		// History only has the show's key, like /library/metadata/25040
+		showID, err := intFromString(path.Base(item.GrandparentKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid show key %q: %w", item.GrandparentKey, err)
+		}
+
 		ret = append(ret, Episode{
 			ID:      id,
 			Title:   item.Title,
 			Show:    ShowTitle(item.GrandparentTitle),
+			ShowID:  showID,
 			Season:  SeasonNumber(season),
 			Episode: EpisodeNumber(index),
 			Watched: viewedAt,
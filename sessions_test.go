@@ -36,3 +36,40 @@ func TestHistorySession(t *testing.T) {
 	require.NoError(t, err)
 	// assert.Equal(t, 65, len(got))
 }
+
+func TestHistoryEpisodesOfShows(t *testing.T) {
+	expected, err := os.ReadFile("./testdata/history-sessions.xml")
+	require.NoError(t, err)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, string(expected))
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	got, err := p.Sessions.HistoryEpisodes(time.Time{}, "American Dad!")
+	require.NoError(t, err)
+	require.Len(t, got, 59)
+	require.Equal(t, 25040, got[0].ShowID)
+
+	// A same-titled show in another library has none of this history
+	require.Len(t, got.OfShows(ShowList{{ID: 25040, Title: "American Dad!"}}), 59)
+	require.Empty(t, got.OfShows(ShowList{{ID: 99999, Title: "American Dad!"}}))
+}
+
+func TestHistoryEpisodesBadShowKey(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `<MediaContainer size="1">
+<Video ratingKey="25187" grandparentKey="/library/metadata/bogus" title="The Life and Times of Stan Smith" grandparentTitle="American Dad!" type="episode" index="15" parentIndex="14" viewedAt="1735013395" />
+</MediaContainer>`)
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	// Dropping the show would stop the episode counting as viewed
+	_, err = p.Sessions.HistoryEpisodes(time.Time{}, "American Dad!")
+	require.ErrorContains(t, err, `invalid show key "/library/metadata/bogus"`)
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -22,50 +26,112 @@ type ShowService interface {
 	Exists(ShowTitle) (bool, error)
 	Match(ShowTitle) (ShowList, error)
 	StrictMatch(ShowTitle) (ShowList, error)
+	Suggest(ShowTitle) ([]ShowTitle, error)
+	ByGUID(string) (ShowList, error)
+	Resolve(EpisodeFilter) (ShowList, error)
+	ByID(int) (*Show, error)
+	Refresh() error
 	Seasons(Show) (*SeasonMap, error)
 	SeasonsSorted(Show) (SeasonList, error)
 	EpisodesWithFilter(ShowList, EpisodeFilter) (EpisodeList, error)
 	Episodes(ShowList) (EpisodeList, error)
+	AllEpisodes(Show) (EpisodeList, error)
 	Episode(ShowTitle, SeasonNumber, EpisodeNumber) (*Episode, error)
+	EpisodeByAbsolute(ShowTitle, int) (*Episode, error)
 	SeasonEpisodes(*Season) (EpisodeMap, error)
 }
 
 // ShowServiceOp implements the ShowService operator.
 type ShowServiceOp struct {
-	p               *Flex
-	cacheDeprecated ShowList
-	// seasonCacheDeprecated map[ShowTitle]*SeasonMap
+	p *Flex
 }
 
 // Episode returns the episode for a given show, season, and episode number.
+// Lookups use a per-show index built from a single request and cached, so
+// only the first lookup for a show goes to the server.
 func (svc *ShowServiceOp) Episode(title ShowTitle, seasonNo SeasonNumber, episodeNo EpisodeNumber) (*Episode, error) {
-	matches, err := svc.Match(title)
+	matches, err := svc.StrictMatch(title)
 	if err != nil {
 		return nil, err
 	}
 	for _, match := range matches {
-		seasons, err := svc.Seasons(*match)
+		idx, err := svc.episodeIndex(*match)
 		if err != nil {
 			return nil, err
 		}
-		for _, season := range *seasons {
-			if season.Index != seasonNo {
-				continue
-			}
-			episodes, err := svc.SeasonEpisodes(season)
-			if err != nil {
-				return nil, err
-			}
-			for _, episode := range episodes {
-				if episode.Episode == episodeNo {
-					return episode, nil
-				}
-			}
+		if episode, ok := idx.bySeason[seasonNo][episodeNo]; ok {
+			return toPTR(*episode), nil
+		}
+	}
+	return nil, errors.New("episode not found")
+}
+
+// EpisodeByAbsolute returns the episode for a given show by its absolute
+// number. Shows the server has no absolute numbering for are numbered in
+// season and episode order, skipping specials.
+func (svc *ShowServiceOp) EpisodeByAbsolute(title ShowTitle, n int) (*Episode, error) {
+	matches, err := svc.StrictMatch(title)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		idx, err := svc.episodeIndex(*match)
+		if err != nil {
+			return nil, err
+		}
+		if episode, ok := idx.byAbsolute[n]; ok {
+			return toPTR(*episode), nil
 		}
 	}
 	return nil, errors.New("episode not found")
 }
 
+// episodeIndexTTL is how long a show's episode index is cached.
+const episodeIndexTTL = time.Hour
+
+// episodeIndex maps season and episode numbers, and absolute numbers, to the
+// episodes of a show.
+type episodeIndex struct {
+	bySeason   map[SeasonNumber]map[EpisodeNumber]*Episode
+	byAbsolute map[int]*Episode
+}
+
+func newEpisodeIndex(l EpisodeList) *episodeIndex {
+	ret := &episodeIndex{
+		bySeason:   map[SeasonNumber]map[EpisodeNumber]*Episode{},
+		byAbsolute: map[int]*Episode{},
+	}
+	for _, item := range l.withAbsoluteIndexes() {
+		if ret.bySeason[item.Season] == nil {
+			ret.bySeason[item.Season] = map[EpisodeNumber]*Episode{}
+		}
+		ret.bySeason[item.Season][item.Episode] = toPTR(item)
+		if item.AbsoluteIndex != 0 {
+			ret.byAbsolute[item.AbsoluteIndex] = ret.bySeason[item.Season][item.Episode]
+		}
+	}
+	return ret
+}
+
+func episodeIndexKey(show Show) string {
+	return fmt.Sprintf("episode-index:%v", show.ID)
+}
+
+// episodeIndex returns the cached episode index for a show, building it if needed.
+func (svc *ShowServiceOp) episodeIndex(show Show) (*episodeIndex, error) {
+	key := episodeIndexKey(show)
+	if got, ok := svc.p.cache.Get(key); ok {
+		return got.(*episodeIndex), nil
+	}
+	episodes, err := svc.leafEpisodes(show, EpisodeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	idx := newEpisodeIndex(episodes)
+	svc.p.cache.Set(key, idx, episodeIndexTTL)
+	return idx, nil
+}
+
 // Seasons returns the seasons for a given show.
 func (svc *ShowServiceOp) Seasons(show Show) (*SeasonMap, error) {
 	if show.Title == "" {
@@ -85,130 +151,221 @@ func (svc *ShowServiceOp) Seasons(show Show) (*SeasonMap, error) {
 			return nil, err
 		}
 		ret[SeasonNumber(item.Index)] = &Season{
-			ID:    id,
-			Index: SeasonNumber(item.Index),
+			ID:                 id,
+			Index:              SeasonNumber(item.Index),
+			Title:              item.Title,
+			EpisodeCount:       item.Leafcount,
+			ViewedEpisodeCount: item.Viewedleafcount,
+			ViewCount:          item.Viewcount,
+			AddedAt:            dateFromUnix(int64(item.Addedat)),
+			LastViewedAt:       dateFromUnix(int64(item.Lastviewedat)),
 		}
 	}
 	return &ret, nil
 }
 
-func (svc *ShowServiceOp) updateCacheDeprecated() error {
-	libs, err := svc.p.Library.List()
+// Exists returns true if a show exists on the server
+func (svc *ShowServiceOp) Exists(name ShowTitle) (bool, error) {
+	got, err := svc.Match(name)
 	if err != nil {
-		return err
+		return false, err
 	}
-	svc.cacheDeprecated = ShowList{}
+	return len(got) > 0, nil
+}
 
-	for _, lib := range libs {
-		if lib.Type != ShowType {
-			continue
-		}
-		shows, err := svc.p.Library.Shows(*lib)
+// StrictMatch returns a *ShowNotFoundError, including suggestions, if no
+// shows are matched.
+func (svc *ShowServiceOp) StrictMatch(name ShowTitle) (ShowList, error) {
+	got, err := svc.Match(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(got) == 0 {
+		suggestions, err := svc.Suggest(name)
 		if err != nil {
-			return err
-		}
-		for _, show := range shows {
-			svc.cacheDeprecated = append(svc.cacheDeprecated, show)
+			return nil, err
 		}
+		return nil, &ShowNotFoundError{Title: name, Suggestions: suggestions}
 	}
-	return nil
+	return got, nil
 }
 
-// Exists returns true if a show exists on the server
-func (svc *ShowServiceOp) Exists(name ShowTitle) (bool, error) {
-	if svc.cacheDeprecated == nil {
-		if err := svc.updateCacheDeprecated(); err != nil {
-			return false, err
-		}
-		/*
-		 */
+// Suggest returns the titles of shows closest to the given name, best first.
+func (svc *ShowServiceOp) Suggest(name ShowTitle) ([]ShowTitle, error) {
+	shows, err := svc.all()
+	if err != nil {
+		return nil, err
+	}
+	return suggestShows(shows, name, maxSuggestions), nil
+}
+
+// ByGUID returns shows with the given Plex GUID (plex://show/...) or external
+// GUID (tvdb://, tmdb:// or imdb://).
+func (svc *ShowServiceOp) ByGUID(guid string) (ShowList, error) {
+	shows, err := svc.all()
+	if err != nil {
+		return nil, err
 	}
-	for _, item := range svc.cacheDeprecated {
-		if name == item.Title {
-			return true, nil
+	ret := ShowList{}
+	for _, show := range shows {
+		if show.HasGUID(guid) {
+			ret = append(ret, show)
 		}
 	}
-	return false, nil
+	return ret, nil
 }
 
-// StrictMatch returns an error if no shows are matched.
-func (svc *ShowServiceOp) StrictMatch(name ShowTitle) (ShowList, error) {
-	got, err := svc.Match(name)
+// Resolve returns the shows an EpisodeFilter refers to, by GUID if one is
+// set, otherwise by title. An error is returned if nothing matches.
+func (svc *ShowServiceOp) Resolve(f EpisodeFilter) (ShowList, error) {
+	if f.GUID == "" {
+		if f.Show == "" {
+			return nil, errors.New("must specify a show or guid")
+		}
+		return svc.StrictMatch(f.Show)
+	}
+	got, err := svc.ByGUID(f.GUID)
 	if err != nil {
 		return nil, err
 	}
 	if len(got) == 0 {
-		return nil, errors.New("no shows found matching: " + string(name))
+		return nil, errors.New("show does not exist with guid: " + f.GUID)
 	}
 	return got, nil
 }
 
-// Match returns shows with the given name.
+// Match returns shows with the given name. An exact title match is preferred,
+// falling back to matching without regard to case, punctuation, diacritics or
+// leading articles. A year may be given to disambiguate, as in "Title (2005)".
 func (svc *ShowServiceOp) Match(name ShowTitle) (ShowList, error) {
-	if svc.cacheDeprecated == nil {
-		if err := svc.updateCacheDeprecated(); err != nil {
-			return nil, err
-		}
-		/*
-			if err := svc.updateCache(); err != nil {
-				return nil, err
-			}
-		*/
+	if err := svc.ensureIndex(); err != nil {
+		return nil, err
 	}
-	ret := ShowList{}
-	for _, show := range svc.cacheDeprecated {
-		if show.Title == name {
-			ret = append(ret, show)
-		}
+	if got := svc.p.showIndex.title(name); len(got) > 0 {
+		return got, nil
 	}
-	return ret, nil
+	return matchShows(svc.p.showIndex.list(), name), nil
+}
+
+// ByID returns the show with the given rating key.
+func (svc *ShowServiceOp) ByID(id int) (*Show, error) {
+	if err := svc.ensureIndex(); err != nil {
+		return nil, err
+	}
+	got := svc.p.showIndex.id(id)
+	if got == nil {
+		return nil, fmt.Errorf("show does not exist with id: %v", id)
+	}
+	return got, nil
 }
 
 // EpisodesWithFilter filters a shows episodes based on the given filter.
 func (svc *ShowServiceOp) EpisodesWithFilter(s ShowList, f EpisodeFilter) (EpisodeList, error) {
-	ret := EpisodeList{}
-	for _, show := range s {
-		// seasons, err := show.Seasons()
-		seasons, err := svc.Seasons(*show)
-		if err != nil {
-			return nil, err
-		}
-		for _, season := range *seasons {
-			if ((f.EarliestSeason != 0) && (season.Index < f.EarliestSeason)) ||
-				((f.LatestSeason != 0) && (season.Index > f.LatestSeason)) {
-				continue
-			}
-			// episodes, err := season.Episodes()
-			episodes, err := svc.SeasonEpisodes(season)
+	return svc.episodes(s, f)
+}
+
+// Episodes returns episodes in a show list.
+func (svc *ShowServiceOp) Episodes(s ShowList) (EpisodeList, error) {
+	return svc.episodes(s, EpisodeFilter{})
+}
+
+// episodes fetches the episodes of every show concurrently, keeping the
+// order of the shows, and only returning seasons included by the filter.
+func (svc *ShowServiceOp) episodes(s ShowList, f EpisodeFilter) (EpisodeList, error) {
+	results := make([]EpisodeList, len(s))
+	g := new(errgroup.Group)
+	g.SetLimit(svc.p.concurrency)
+	for idx, show := range s {
+		g.Go(func() error {
+			got, err := svc.leafEpisodes(*show, f)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			for _, episode := range episodes {
-				ret = append(ret, *episode)
-			}
-		}
+			results[idx] = got.filter(f)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	ret := EpisodeList{}
+	for _, item := range results {
+		ret = append(ret, item...)
 	}
 	return ret, nil
 }
 
-// Episodes returns episodes in a show list.
-func (svc *ShowServiceOp) Episodes(s ShowList) (EpisodeList, error) {
+// AllEpisodes returns every episode of a show with a single request.
+func (svc *ShowServiceOp) AllEpisodes(show Show) (EpisodeList, error) {
+	var er EpisodesResponse
+	if err := svc.p.sendRequestXML(
+		mustNewRequest(http.MethodGet, fmt.Sprintf("%v/library/metadata/%v/allLeaves", svc.p.baseURL, show.ID)),
+		&er,
+		nil,
+	); err != nil {
+		return nil, err
+	}
 	ret := EpisodeList{}
-	for _, show := range s {
-		// seasons, err := show.Seasons()
-		seasons, err := svc.Seasons(*show)
+	for _, item := range er.Video {
+		if item.RatingKey == "" {
+			continue
+		}
+		e, err := episodeWithVideo(item)
 		if err != nil {
 			return nil, err
 		}
-		for _, season := range *seasons {
+		ret = append(ret, *e)
+	}
+	sort.Sort(ret)
+	return ret, nil
+}
+
+// leafEpisodes returns the episodes of a show using AllEpisodes, only walking
+// the seasons included by the filter when the server doesn't support
+// allLeaves. Any other error is returned.
+func (svc *ShowServiceOp) leafEpisodes(show Show, f EpisodeFilter) (EpisodeList, error) {
+	episodes, err := svc.AllEpisodes(show)
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return episodes, nil
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusBadRequest):
+		svc.p.logger.Debug("allLeaves unsupported, falling back to walking seasons", "show", show.Title, "error", err)
+		return svc.seasonWalkEpisodes(show, f)
+	default:
+		return nil, err
+	}
+}
+
+// seasonWalkEpisodes returns the episodes of a show by fetching each season
+// included by the filter concurrently.
+func (svc *ShowServiceOp) seasonWalkEpisodes(show Show, f EpisodeFilter) (EpisodeList, error) {
+	seasons, err := svc.SeasonsSorted(show)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]EpisodeList, len(seasons))
+	g := new(errgroup.Group)
+	g.SetLimit(svc.p.concurrency)
+	for idx, season := range seasons {
+		if !f.includesSeason(season.Index) {
+			continue
+		}
+		g.Go(func() error {
 			episodes, err := svc.SeasonEpisodes(season)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			for _, episode := range episodes {
-				ret = append(ret, *episode)
-			}
-		}
+			results[idx] = episodes.List()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	ret := EpisodeList{}
+	for _, item := range results {
+		ret = append(ret, item...)
 	}
 	return ret, nil
 }
@@ -267,12 +424,85 @@ type ShowTitle string
 type Show struct {
 	ID    int
 	Title ShowTitle
+	// GUID is the Plex identifier for the show, such as plex://show/5d9c086c7d06d9001ffd27b2
+	GUID string
+	// ExternalGUIDs are identifiers from other agents, such as tvdb://71663
+	ExternalGUIDs  []string
+	Studio         string
+	ContentRating  string
+	Year           int
+	AudienceRating float64
+	Genres         []string
+	Labels         []string
+	// EpisodeCount is the number of episodes in the show (leafCount)
+	EpisodeCount int
+	// ViewedEpisodeCount is the number of episodes watched at least once (viewedLeafCount)
+	ViewedEpisodeCount int
+	// SeasonCount is the number of seasons in the show (childCount)
+	SeasonCount  int
+	ViewCount    int
+	AddedAt      *time.Time
+	UpdatedAt    *time.Time
+	LastViewedAt *time.Time
+}
+
+// Progress returns the percentage of episodes in the show that have been watched.
+func (s Show) Progress() float64 {
+	return progress(s.ViewedEpisodeCount, s.EpisodeCount)
+}
+
+// UnviewedEpisodeCount returns the number of episodes that have never been watched.
+func (s Show) UnviewedEpisodeCount() int {
+	return max(s.EpisodeCount-s.ViewedEpisodeCount, 0)
+}
+
+// FullyWatched returns true if every episode in the show has been watched.
+func (s Show) FullyWatched() bool {
+	return (s.EpisodeCount > 0) && (s.UnviewedEpisodeCount() == 0)
+}
+
+// HasGenre returns true if the show is tagged with the given genre.
+func (s Show) HasGenre(genre string) bool {
+	return containsFold(s.Genres, genre)
+}
+
+// HasLabel returns true if the show is tagged with the given label.
+func (s Show) HasLabel(label string) bool {
+	return containsFold(s.Labels, label)
+}
+
+// HasGUID returns true if the show is identified by the given Plex or external GUID.
+func (s Show) HasGUID(guid string) bool {
+	return strings.EqualFold(s.GUID, guid) || containsFold(s.ExternalGUIDs, guid)
 }
 
 // Season represents a season in a TV show.
 type Season struct {
-	ID    int
-	Index SeasonNumber
+	ID                 int
+	Index              SeasonNumber
+	Title              string
+	EpisodeCount       int
+	ViewedEpisodeCount int
+	ViewCount          int
+	AddedAt            *time.Time
+	LastViewedAt       *time.Time
+}
+
+// Progress returns the percentage of episodes in the season that have been watched.
+func (s Season) Progress() float64 {
+	return progress(s.ViewedEpisodeCount, s.EpisodeCount)
+}
+
+// FullyWatched returns true if every episode in the season has been watched.
+func (s Season) FullyWatched() bool {
+	return (s.EpisodeCount > 0) && (s.ViewedEpisodeCount >= s.EpisodeCount)
+}
+
+func progress(viewed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(viewed) / float64(total) * 100
 }
 
 // SeasonList is a list of Seasons
@@ -303,17 +533,125 @@ func (s SeasonMap) sorted() SeasonList {
 // ShowList represents multiple shows
 type ShowList []*Show
 
+// WithGenre returns the shows in the list tagged with the given genre.
+func (l ShowList) WithGenre(genre string) ShowList {
+	ret := ShowList{}
+	for _, show := range l {
+		if show.HasGenre(genre) {
+			ret = append(ret, show)
+		}
+	}
+	return ret
+}
+
+// Titles returns the unique titles of the shows in the list.
+func (l ShowList) Titles() []ShowTitle {
+	ret := []ShowTitle{}
+	for _, show := range l {
+		if !slices.Contains(ret, show.Title) {
+			ret = append(ret, show.Title)
+		}
+	}
+	return ret
+}
+
+// Unfinished returns the shows in the list that still have unwatched episodes.
+func (l ShowList) Unfinished() ShowList {
+	ret := ShowList{}
+	for _, show := range l {
+		if !show.FullyWatched() {
+			ret = append(ret, show)
+		}
+	}
+	return ret
+}
+
+// WithLabel returns the shows in the list tagged with the given label.
+func (l ShowList) WithLabel(label string) ShowList {
+	ret := ShowList{}
+	for _, show := range l {
+		if show.HasLabel(label) {
+			ret = append(ret, show)
+		}
+	}
+	return ret
+}
+
 func showWith(m Metadata) (*Show, error) {
 	id, err := strconv.Atoi(m.RatingKey)
 	if err != nil {
 		return nil, err
 	}
 	return &Show{
-		ID:    id,
-		Title: ShowTitle(m.Title),
+		ID:                 id,
+		Title:              ShowTitle(m.Title),
+		GUID:               m.GUID,
+		ExternalGUIDs:      guidIDs(m.GUIDs),
+		Studio:             m.Studio,
+		ContentRating:      m.ContentRating,
+		Year:               m.Year,
+		AudienceRating:     m.AudienceRating,
+		Genres:             tagNames(m.Genre),
+		Labels:             tagNames(m.Label),
+		EpisodeCount:       m.LeafCount,
+		ViewedEpisodeCount: m.ViewedLeafCount,
+		SeasonCount:        m.ChildCount,
+		ViewCount:          m.ViewCount,
+		AddedAt:            dateFromUnix(int64(m.AddedAt)),
+		UpdatedAt:          dateFromUnix(int64(m.UpdatedAt)),
+		LastViewedAt:       dateFromUnix(int64(m.LastViewedAt)),
 	}, nil
 }
 
+func showWithDirectory(d ShowDirectory) (*Show, error) {
+	id, err := strconv.Atoi(d.RatingKey)
+	if err != nil {
+		return nil, err
+	}
+	ret := &Show{
+		ID:            id,
+		Title:         ShowTitle(d.Title),
+		GUID:          d.GUID,
+		ExternalGUIDs: guidIDs(d.GUIDs),
+		Studio:        d.Studio,
+		ContentRating: d.ContentRating,
+		Genres:        tagNames(d.Genre),
+		Labels:        tagNames(d.Label),
+	}
+	if ret.AudienceRating, err = floatFromString(d.AudienceRating); err != nil {
+		return nil, fmt.Errorf("error converting AudienceRating: %w", err)
+	}
+	for _, item := range []struct {
+		name  string
+		value string
+		dest  *int
+	}{
+		{"Year", d.Year, &ret.Year},
+		{"LeafCount", d.LeafCount, &ret.EpisodeCount},
+		{"ViewedLeafCount", d.ViewedLeafCount, &ret.ViewedEpisodeCount},
+		{"ChildCount", d.ChildCount, &ret.SeasonCount},
+		{"ViewCount", d.ViewCount, &ret.ViewCount},
+	} {
+		if *item.dest, err = intFromString(item.value); err != nil {
+			return nil, fmt.Errorf("error converting %v: %w", item.name, err)
+		}
+	}
+	for _, item := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"AddedAt", d.AddedAt, &ret.AddedAt},
+		{"UpdatedAt", d.UpdatedAt, &ret.UpdatedAt},
+		{"LastViewedAt", d.LastViewedAt, &ret.LastViewedAt},
+	} {
+		if *item.dest, err = optionalDateFromUnixString(item.value); err != nil {
+			return nil, fmt.Errorf("error converting %v: %w", item.name, err)
+		}
+	}
+	return ret, nil
+}
+
 type seasonsResponse struct {
 	Mediacontainer struct {
 		Size                int    `json:"size"`
@@ -0,0 +1,215 @@
+package goflex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultShowIndexTTL is how long the show index is used before it is
+	// always rebuilt.
+	defaultShowIndexTTL = time.Hour
+	// defaultShowIndexCheck is how often the libraries are checked for changes
+	// that should rebuild the show index early.
+	defaultShowIndexCheck = time.Minute * 5
+)
+
+// showIndex is an index of every show in every show library on the server,
+// keyed by title and ID. It is safe to share across goroutines.
+type showIndex struct {
+	mu            sync.RWMutex
+	buildMu       sync.Mutex
+	shows         ShowList
+	byTitle       map[ShowTitle]ShowList
+	byID          map[int]*Show
+	fingerprint   string
+	builtAt       time.Time
+	checkedAt     time.Time
+	ttl           time.Duration
+	checkInterval time.Duration
+}
+
+func newShowIndex() *showIndex {
+	return &showIndex{
+		ttl:           defaultShowIndexTTL,
+		checkInterval: defaultShowIndexCheck,
+	}
+}
+
+// WithShowIndexTTL sets how long the show index is used before it is rebuilt.
+// Libraries are still checked for changes more often than this.
+func WithShowIndexTTL(d time.Duration) func(*Flex) {
+	return func(p *Flex) {
+		p.showIndex.ttl = d
+	}
+}
+
+// list returns every show in the index.
+func (idx *showIndex) list() ShowList {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return slices.Clone(idx.shows)
+}
+
+// title returns shows with exactly the given title.
+func (idx *showIndex) title(t ShowTitle) ShowList {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return slices.Clone(idx.byTitle[t])
+}
+
+// id returns the show with the given rating key, or nil.
+func (idx *showIndex) id(i int) *Show {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byID[i]
+}
+
+// invalidate forces the next use of the index to rebuild it.
+func (idx *showIndex) invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.builtAt = time.Time{}
+}
+
+// set replaces the contents of the index.
+func (idx *showIndex) set(shows ShowList, fingerprint string) {
+	byTitle := map[ShowTitle]ShowList{}
+	byID := make(map[int]*Show, len(shows))
+	for _, show := range shows {
+		byTitle[show.Title] = append(byTitle[show.Title], show)
+		byID[show.ID] = show
+	}
+	now := time.Now()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.shows = shows
+	idx.byTitle = byTitle
+	idx.byID = byID
+	idx.fingerprint = fingerprint
+	idx.builtAt = now
+	idx.checkedAt = now
+}
+
+// state reports whether the index must be rebuilt, or just checked against
+// the libraries for changes.
+func (idx *showIndex) state() (stale bool, check bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	now := time.Now()
+	stale = idx.builtAt.IsZero() || now.Sub(idx.builtAt) > idx.ttl
+	check = now.Sub(idx.checkedAt) > idx.checkInterval
+	return stale, check
+}
+
+// unchanged returns true, and marks the index as checked, if the libraries
+// still match the fingerprint the index was built with.
+func (idx *showIndex) unchanged(fingerprint string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.fingerprint != fingerprint {
+		return false
+	}
+	idx.checkedAt = time.Now()
+	return true
+}
+
+// libraryFingerprint summarizes the show libraries, changing whenever
+// content is added to or removed from one of them.
+func libraryFingerprint(libs LibraryMap) string {
+	parts := []string{}
+	for _, lib := range libs {
+		if lib.Type != ShowType {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%v:%v:%v", lib.ID, lib.ContentChangedAt, fromPTR(lib.ScannedAt).Unix()))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
+
+// ensureIndex makes sure the show index is built and reasonably fresh.
+func (svc *ShowServiceOp) ensureIndex() error {
+	idx := svc.p.showIndex
+	stale, check := idx.state()
+	if !stale && !check {
+		return nil
+	}
+
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+	// Someone else may have rebuilt while we waited
+	if stale, check = idx.state(); !stale && !check {
+		return nil
+	}
+
+	// Always look at the libraries fresh, as the list is cached for a while
+	svc.p.cache.DeletePrefix("library-list")
+	libs, err := svc.p.Library.List()
+	if err != nil {
+		return err
+	}
+	fingerprint := libraryFingerprint(libs)
+	if !stale {
+		if idx.unchanged(fingerprint) {
+			return nil
+		}
+		svc.p.logger.Debug("show libraries changed, rebuilding show index")
+	}
+	return svc.buildIndex(libs, fingerprint)
+}
+
+// buildIndex walks every show library and replaces the index. Cached episode
+// indexes are dropped too, as the libraries they came from have changed.
+func (svc *ShowServiceOp) buildIndex(libs LibraryMap, fingerprint string) error {
+	svc.p.cache.DeletePrefix("shows")
+	svc.p.cache.DeletePrefix("episode-index")
+	shows := ShowList{}
+	for _, lib := range libs {
+		if lib.Type != ShowType {
+			continue
+		}
+		got, err := svc.p.Library.Shows(*lib)
+		if err != nil {
+			return err
+		}
+		for _, show := range got {
+			shows = append(shows, show)
+		}
+	}
+	slices.SortFunc(shows, func(a, b *Show) int {
+		if c := strings.Compare(string(a.Title), string(b.Title)); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	svc.p.logger.Debug("built show index", "shows", len(shows))
+	svc.p.showIndex.set(shows, fingerprint)
+	return nil
+}
+
+// Refresh rebuilds the show index now, picking up any shows added since it
+// was last built. Cached episode indexes are dropped as well.
+func (svc *ShowServiceOp) Refresh() error {
+	idx := svc.p.showIndex
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+	svc.p.cache.DeletePrefix("library-list")
+	libs, err := svc.p.Library.List()
+	if err != nil {
+		return err
+	}
+	return svc.buildIndex(libs, libraryFingerprint(libs))
+}
+
+// all returns every show on the server from the index.
+func (svc *ShowServiceOp) all() (ShowList, error) {
+	if err := svc.ensureIndex(); err != nil {
+		return nil, err
+	}
+	return svc.p.showIndex.list(), nil
+}
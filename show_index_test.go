@@ -0,0 +1,109 @@
+package goflex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowIndexRefresh(t *testing.T) {
+	libraries, err := os.ReadFile("./testdata/libraries.xml")
+	require.NoError(t, err)
+	shows, err := os.ReadFile("./testdata/shows.xml")
+	require.NoError(t, err)
+
+	var showHits atomic.Int32
+	var mu sync.Mutex
+	currentLibraries := string(libraries)
+	currentShows := string(shows)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(req.URL.Path, "/library/sections/") {
+			fmt.Fprint(w, currentLibraries)
+			return
+		}
+		showHits.Add(1)
+		fmt.Fprint(w, currentShows)
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	exists, err := p.Shows.Exists("Brand New Show")
+	require.NoError(t, err)
+	require.False(t, exists)
+	built := showHits.Load()
+	require.Positive(t, built)
+
+	// A show gets added, but the index doesn't know yet
+	mu.Lock()
+	currentShows = strings.Replace(currentShows, `title="American Dad!"`, `title="Brand New Show"`, 1)
+	mu.Unlock()
+	exists, err = p.Shows.Exists("Brand New Show")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Equal(t, built, showHits.Load())
+
+	// Once the library reports a change, the next check rebuilds, dropping
+	// episode indexes built from the old libraries
+	p.cache.Set(episodeIndexKey(Show{ID: 25040}), newEpisodeIndex(EpisodeList{}), episodeIndexTTL)
+	mu.Lock()
+	currentLibraries = strings.ReplaceAll(currentLibraries, `contentChangedAt="`, `contentChangedAt="1`)
+	mu.Unlock()
+	p.showIndex.checkInterval = 0
+	exists, err = p.Shows.Exists("Brand New Show")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Greater(t, showHits.Load(), built)
+	require.False(t, p.cache.exists(episodeIndexKey(Show{ID: 25040})))
+
+	// Unchanged libraries don't trigger a rebuild, even when checked
+	built = showHits.Load()
+	_, err = p.Shows.Match("Brand New Show")
+	require.NoError(t, err)
+	require.Equal(t, built, showHits.Load())
+
+	// Refresh always rebuilds
+	require.NoError(t, p.Shows.Refresh())
+	require.Greater(t, showHits.Load(), built)
+
+	got, err := p.Shows.ByID(25040)
+	require.NoError(t, err)
+	assert.EqualValues(t, "Brand New Show", got.Title)
+	_, err = p.Shows.ByID(1)
+	require.EqualError(t, err, "show does not exist with id: 1")
+}
+
+func TestShowIndexConcurrent(t *testing.T) {
+	svr := showsServer(t)
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+	p.showIndex.checkInterval = 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := p.Shows.Match("American Dad!")
+			assert.NoError(t, err)
+			assert.Len(t, got, 2)
+			if i%3 == 0 {
+				assert.NoError(t, p.Shows.Refresh())
+			}
+		}()
+	}
+	wg.Wait()
+}
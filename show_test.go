@@ -5,12 +5,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestSortEpisodes(t *testing.T) {
@@ -56,6 +60,25 @@ func TestShowSeasons(t *testing.T) {
 	s := *seasons
 	assert.EqualValues(t, 1, s[1].Index)
 	assert.EqualValues(t, 20, s[20].Index)
+	assert.Equal(t, "Season 2", s[2].Title)
+	assert.Equal(t, 16, s[2].EpisodeCount)
+	assert.True(t, s[2].FullyWatched())
+	assert.Equal(t, float64(100), s[2].Progress())
+}
+
+func TestShowProgress(t *testing.T) {
+	shows := ShowList{
+		{Title: "done", EpisodeCount: 10, ViewedEpisodeCount: 10},
+		{Title: "half", EpisodeCount: 10, ViewedEpisodeCount: 5},
+		{Title: "empty"},
+	}
+	assert.Equal(t, float64(50), shows[1].Progress())
+	assert.Equal(t, float64(0), shows[2].Progress())
+	assert.True(t, shows[0].FullyWatched())
+	assert.False(t, shows[2].FullyWatched())
+	unfinished := shows.Unfinished()
+	require.Len(t, unfinished, 2)
+	assert.EqualValues(t, "half", unfinished[0].Title)
 }
 
 func TestEpisodeMapList(t *testing.T) {
@@ -142,6 +165,71 @@ func TestSubtract(t *testing.T) {
 	}, removed)
 }
 
+func TestSubtractSharedFiles(t *testing.T) {
+	file := func(f string) []EpisodeMedia {
+		return []EpisodeMedia{{Parts: []MediaPart{{File: f}}}}
+	}
+	given := EpisodeList{
+		{Show: "foo", Season: 1, Episode: 1, Media: file("/tv/foo/S01E01-E02.mkv")},
+		{Show: "foo", Season: 1, Episode: 2, Media: file("/tv/foo/S01E01-E02.mkv")},
+		{Show: "foo", Season: 1, Episode: 3, Media: file("/tv/foo/S01E03-E04.mkv")},
+		{Show: "foo", Season: 1, Episode: 4, Media: file("/tv/foo/S01E03-E04.mkv")},
+		{Show: "foo", Season: 1, Episode: 5, Media: file("/tv/foo/S01E05.mkv")},
+	}
+	remaining, removed := given.Subtract(EpisodeList{
+		{Show: "foo", Season: 1, Episode: 4, Media: file("/tv/foo/S01E03-E04.mkv")},
+	})
+	require.Len(t, remaining, 2)
+	assert.EqualValues(t, 1, remaining[0].Episode)
+	assert.EqualValues(t, 5, remaining[1].Episode)
+	require.Len(t, removed, 3)
+	assert.EqualValues(t, 2, removed[0].Episode)
+	assert.EqualValues(t, 3, removed[1].Episode)
+	assert.EqualValues(t, 4, removed[2].Episode)
+}
+
+func TestSubtractDuplicatePlaylistItem(t *testing.T) {
+	file := func(f ...string) []EpisodeMedia {
+		parts := []MediaPart{}
+		for _, item := range f {
+			parts = append(parts, MediaPart{File: item})
+		}
+		return []EpisodeMedia{{Parts: parts}}
+	}
+	playlist := EpisodeList{
+		{Show: "foo", Season: 1, Episode: 1, PlaylistItemID: 11, Media: file("/tv/foo/S01E01-E02.mkv")},
+		{Show: "foo", Season: 1, Episode: 2, PlaylistItemID: 12, Media: file("/tv/foo/S01E01-E02.mkv")},
+		{Show: "foo", Season: 1, Episode: 3, PlaylistItemID: 13, Media: file("/tv/foo/S01E03.cd1.mkv", "/tv/foo/S01E03.cd2.mkv")},
+		{Show: "foo", Season: 1, Episode: 4, PlaylistItemID: 14, Media: file("/tv/foo/S01E03.cd1.mkv", "/tv/foo/S01E04.mkv")},
+	}
+
+	// Nothing was watched, but the second item of the shared file is removed
+	// so it can be deleted, and every item is accounted for
+	remaining, removed := playlist.Subtract(EpisodeList{})
+	require.Len(t, remaining, 3)
+	assert.EqualValues(t, 1, remaining[0].Episode)
+	require.Len(t, removed, 1)
+	assert.Equal(t, 12, removed[0].PlaylistItemID)
+
+	// Sharing only the first part isn't the same file
+	assert.EqualValues(t, 3, remaining[1].Episode)
+	assert.EqualValues(t, 4, remaining[2].Episode)
+}
+
+func TestWithAbsoluteIndexes(t *testing.T) {
+	got := EpisodeList{
+		{Season: 2, Episode: 1},
+		{Season: 0, Episode: 1},
+		{Season: 1, Episode: 2},
+		{Season: 1, Episode: 1},
+	}.withAbsoluteIndexes()
+	assert.Equal(t, []int{0, 1, 2, 3}, []int{got[0].AbsoluteIndex, got[1].AbsoluteIndex, got[2].AbsoluteIndex, got[3].AbsoluteIndex})
+
+	// Server provided numbering is kept as is
+	got = EpisodeList{{Season: 1, Episode: 1, AbsoluteIndex: 40}}.withAbsoluteIndexes()
+	assert.Equal(t, 40, got[0].AbsoluteIndex)
+}
+
 func TestEpisodeSeasons(t *testing.T) {
 	everything := EpisodeList{
 		{Title: "s01e01", Season: 1},
@@ -182,6 +270,40 @@ func TestEpisodeSeasons(t *testing.T) {
 	}
 }
 
+func TestEpisodeSeasonsSpecials(t *testing.T) {
+	everything := EpisodeList{
+		{Title: "s00e01", Season: 0},
+		{Title: "s01e01", Season: 1},
+		{Title: "s02e01", Season: 2},
+	}
+	tests := map[string]struct {
+		givenStart    SeasonNumber
+		givenEnd      SeasonNumber
+		givenSpecials SpecialsPolicy
+		expect        []string
+	}{
+		"default-with-start": {givenStart: 1, expect: []string{"s01e01", "s02e01"}},
+		"default-with-end":   {givenEnd: 1, expect: []string{"s00e01", "s01e01"}},
+		"include":            {givenStart: 2, givenSpecials: SpecialsInclude, expect: []string{"s00e01", "s02e01"}},
+		"exclude":            {givenSpecials: SpecialsExclude, expect: []string{"s01e01", "s02e01"}},
+		"only":               {givenStart: 1, givenSpecials: SpecialsOnly, expect: []string{"s00e01"}},
+	}
+	for desc, tt := range tests {
+		got := []string{}
+		for _, e := range everything.Seasons(tt.givenStart, tt.givenEnd, tt.givenSpecials) {
+			got = append(got, e.Title)
+		}
+		require.Equal(t, tt.expect, got, desc)
+	}
+}
+
+func TestSpecialsPolicyYAML(t *testing.T) {
+	var f EpisodeFilter
+	require.NoError(t, yaml.Unmarshal([]byte("show: Futurama\nspecials: only\n"), &f))
+	require.Equal(t, SpecialsOnly, f.Specials)
+	require.EqualError(t, yaml.Unmarshal([]byte("specials: sometimes\n"), &f), "invalid specials policy: sometimes")
+}
+
 func TestSeasonMap(t *testing.T) {
 	require.Equal(t, SeasonList{
 		{Index: 1},
@@ -193,3 +315,203 @@ func TestSeasonMap(t *testing.T) {
 		3: &Season{Index: 3},
 	}.sorted())
 }
+
+// showsServer serves the library list, and the same shows for every library.
+func showsServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/library/sections/") {
+			expected, err := os.ReadFile("./testdata/libraries.xml")
+			assert.NoError(t, err)
+			fmt.Fprint(w, string(expected))
+			return
+		}
+		expected, err := os.ReadFile("./testdata/shows.xml")
+		assert.NoError(t, err)
+		fmt.Fprint(w, string(expected))
+	}))
+}
+
+func TestShowsByGUID(t *testing.T) {
+	svr := showsServer(t)
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	for _, guid := range []string{"tvdb://73141", "TMDB://1433", "plex://show/5d9c086c7d06d9001ffd27b2"} {
+		got, err := p.Shows.ByGUID(guid)
+		require.NoError(t, err, guid)
+		require.Len(t, got, 2, guid)
+		assert.EqualValues(t, "American Dad!", got[0].Title, guid)
+	}
+
+	got, err := p.Shows.Resolve(EpisodeFilter{Show: "Never Exists", GUID: "imdb://tt0397306"})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, []string{"imdb://tt0397306", "tmdb://1433", "tvdb://73141"}, got[0].ExternalGUIDs)
+
+	_, err = p.Shows.Resolve(EpisodeFilter{GUID: "tvdb://0"})
+	require.EqualError(t, err, "show does not exist with guid: tvdb://0")
+
+	_, err = p.Shows.Resolve(EpisodeFilter{})
+	require.EqualError(t, err, "must specify a show or guid")
+}
+
+func TestAllEpisodes(t *testing.T) {
+	var hits atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		assert.Equal(t, "/library/metadata/1998/allLeaves", req.URL.Path)
+		expected, err := os.ReadFile("./testdata/episodes.xml")
+		assert.NoError(t, err)
+		fmt.Fprint(w, string(expected))
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	koth := ShowList{{ID: 1998, Title: "King of the Hill"}}
+	got, err := p.Shows.Episodes(koth)
+	require.NoError(t, err)
+	require.Len(t, got, 12)
+	assert.Equal(t, "The Peggy Horror Picture Show", got[0].Title)
+	assert.True(t, sort.IsSorted(got))
+	assert.EqualValues(t, 1, hits.Load())
+	assert.Equal(t, []string{"/srv/nfs/tv/King of the Hill/S11/S11E01.mkv"}, got[0].Files())
+	assert.Equal(t, int64(447150550), got[0].Media[0].Parts[0].Size)
+
+	got, err = p.Shows.EpisodesWithFilter(koth, EpisodeFilter{LatestSeason: 10})
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestEpisodesSeasonWalkFallback(t *testing.T) {
+	var seasonHits atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/library/metadata/1/allLeaves":
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.HasSuffix(req.URL.Path, "/allLeaves"):
+			w.WriteHeader(http.StatusNotFound)
+		case req.URL.Path == "/library/metadata/25040/children":
+			expected, err := os.ReadFile("./testdata/seasons.json")
+			assert.NoError(t, err)
+			fmt.Fprint(w, string(expected))
+		default:
+			seasonHits.Add(1)
+			expected, err := os.ReadFile("./testdata/episodes.xml")
+			assert.NoError(t, err)
+			fmt.Fprint(w, string(expected))
+		}
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"), WithConcurrency(8))
+	require.NoError(t, err)
+
+	got, err := p.Shows.Episodes(ShowList{{ID: 25040, Title: "American Dad!"}})
+	require.NoError(t, err)
+	assert.Len(t, got, 21*12)
+	assert.EqualValues(t, 21, seasonHits.Load())
+
+	// Only servers without allLeaves fall back, other errors are returned
+	_, err = p.Shows.Episodes(ShowList{{ID: 1, Title: "Broken"}})
+	require.EqualError(t, err, "unknown error, status code: 500")
+	assert.EqualValues(t, 21, seasonHits.Load())
+
+	_, err = New(WithBaseURL(svr.URL), WithToken("test-token"), WithConcurrency(0))
+	require.EqualError(t, err, "concurrency must be at least 1")
+}
+
+func TestEpisodesConcurrencyLimit(t *testing.T) {
+	var inFlight, most atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			prev := most.Load()
+			if n <= prev || most.CompareAndSwap(prev, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/allLeaves"):
+			w.WriteHeader(http.StatusNotFound)
+		case slices.Contains([]string{"/library/metadata/1/children", "/library/metadata/2/children", "/library/metadata/3/children"}, req.URL.Path):
+			expected, err := os.ReadFile("./testdata/seasons.json")
+			assert.NoError(t, err)
+			fmt.Fprint(w, string(expected))
+		default:
+			expected, err := os.ReadFile("./testdata/episodes.xml")
+			assert.NoError(t, err)
+			fmt.Fprint(w, string(expected))
+		}
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"), WithConcurrency(3))
+	require.NoError(t, err)
+
+	// Walking the seasons of several shows at once stays within the limit
+	got, err := p.Shows.Episodes(ShowList{{ID: 1, Title: "One"}, {ID: 2, Title: "Two"}, {ID: 3, Title: "Three"}})
+	require.NoError(t, err)
+	assert.Len(t, got, 3*21*12)
+	assert.LessOrEqual(t, most.Load(), int32(3))
+}
+
+func TestEpisodeIndexed(t *testing.T) {
+	var leafHits atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var f string
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/library/sections/"):
+			f = "./testdata/libraries.xml"
+		case strings.HasSuffix(req.URL.Path, "/allLeaves"):
+			assert.Equal(t, "/library/metadata/1998/allLeaves", req.URL.Path)
+			leafHits.Add(1)
+			f = "./testdata/episodes.xml"
+		default:
+			f = "./testdata/shows.xml"
+		}
+		expected, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		fmt.Fprint(w, string(expected))
+	}))
+	defer svr.Close()
+
+	p, err := New(WithBaseURL(svr.URL), WithToken("test-token"))
+	require.NoError(t, err)
+
+	got, err := p.Shows.Episode("King of the Hill", 11, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2211, got.ID)
+	assert.Equal(t, "The Peggy Horror Picture Show", got.Title)
+
+	got, err = p.Shows.Episode("king of the hill", 11, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, got.Episode)
+
+	_, err = p.Shows.Episode("King of the Hill", 1, 1)
+	require.EqualError(t, err, "episode not found")
+	assert.EqualValues(t, 1, leafHits.Load())
+
+	id, err := p.episodeID("King of the Hill", 11, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2211, id)
+	assert.EqualValues(t, 1, leafHits.Load())
+
+	got, err = p.Shows.EpisodeByAbsolute("King of the Hill", 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, got.Episode)
+	_, err = p.Shows.EpisodeByAbsolute("King of the Hill", 99)
+	require.EqualError(t, err, "episode not found")
+	assert.EqualValues(t, 1, leafHits.Load())
+
+	// Refreshing the show index drops the episode indexes too
+	require.NoError(t, p.Shows.Refresh())
+	_, err = p.Shows.Episode("King of the Hill", 11, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, leafHits.Load())
+}
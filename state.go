@@ -0,0 +1,154 @@
+package goflex
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlaylistState is what is remembered about a randomized playlist between
+// runs, so a restart can pick the schedule back up.
+type PlaylistState struct {
+	Server       string        `json:"server" yaml:"server"`
+	Playlist     PlaylistTitle `json:"playlist" yaml:"playlist"`
+	LastRefill   time.Time     `json:"last_refill,omitzero" yaml:"last_refill,omitempty"`
+	RefillReason string        `json:"refill_reason,omitempty" yaml:"refill_reason,omitempty"`
+	Seed         *uint64       `json:"seed,omitempty" yaml:"seed,omitempty"`
+	Inserted     []string      `json:"inserted,omitempty" yaml:"inserted,omitempty"`
+	LastCheck    time.Time     `json:"last_check,omitzero" yaml:"last_check,omitempty"`
+	NextCheck    time.Time     `json:"next_check,omitzero" yaml:"next_check,omitempty"`
+	// Fingerprint identifies the request the schedule was saved for, so a
+	// changed request doesn't wait on a stale next check.
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+}
+
+// WithResponse returns the state updated with the result of a randomize that
+// finished at the given time. Dry runs change nothing.
+func (s PlaylistState) WithResponse(resp *RandomizeResponse, now time.Time) PlaylistState {
+	if resp == nil || resp.DryRun {
+		return s
+	}
+	s.LastCheck = now
+	s.NextCheck = now.Add(resp.SleepFor)
+	if resp.Refilled {
+		s.LastRefill = now
+		s.RefillReason = resp.RefillReason
+		s.Seed = &resp.Seed
+		s.Inserted = make([]string, len(resp.UnviewedEpisodes))
+		for idx, episode := range resp.UnviewedEpisodes {
+			s.Inserted[idx] = episode.String()
+		}
+	}
+	return s
+}
+
+// StateStore keeps PlaylistStates in a JSON file.
+type StateStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStateStore returns a store using the file at path, which is created on
+// the first Put.
+func NewStateStore(path string) *StateStore {
+	return &StateStore{path: path}
+}
+
+// Path returns the file the store is kept in.
+func (s *StateStore) Path() string {
+	return s.path
+}
+
+// List returns every stored state, sorted by server and playlist. A missing
+// file is an empty store.
+func (s *StateStore) List() ([]PlaylistState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Get returns the state of a playlist on a server, and whether there was one.
+func (s *StateStore) Get(server string, playlist PlaylistTitle) (PlaylistState, bool, error) {
+	states, err := s.List()
+	if err != nil {
+		return PlaylistState{}, false, err
+	}
+	for _, state := range states {
+		if state.Server == server && state.Playlist == playlist {
+			return state, true, nil
+		}
+	}
+	return PlaylistState{}, false, nil
+}
+
+// Put stores the state, replacing any earlier state for the same server and
+// playlist.
+func (s *StateStore) Put(state PlaylistState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+	states = slices.DeleteFunc(states, func(item PlaylistState) bool {
+		return item.Server == state.Server && item.Playlist == state.Playlist
+	})
+	states = append(states, state)
+	sortStates(states)
+	return s.write(states)
+}
+
+func (s *StateStore) read() ([]PlaylistState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []PlaylistState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var states []PlaylistState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	sortStates(states)
+	return states, nil
+}
+
+// write replaces the file in one go, so a crash never leaves half a store.
+func (s *StateStore) write(states []PlaylistState) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func sortStates(states []PlaylistState) {
+	slices.SortFunc(states, func(a, b PlaylistState) int {
+		if c := strings.Compare(a.Server, b.Server); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Playlist), string(b.Playlist))
+	})
+}
@@ -0,0 +1,68 @@
+package goflex
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore(t *testing.T) {
+	s := NewStateStore(filepath.Join(t.TempDir(), "goflex", "state.json"))
+	got, err := s.List()
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	state := PlaylistState{Server: "http://plex.example.com", Playlist: "Foo", Fingerprint: "abc"}.WithResponse(&RandomizeResponse{
+		RefillReason:     "newly created playlist",
+		Refilled:         true,
+		Seed:             42,
+		SleepFor:         time.Hour,
+		UnviewedEpisodes: EpisodeList{{Show: "Bar", Season: 1, Episode: 2, Title: "Baz"}},
+	}, now)
+	require.Equal(t, now, state.LastRefill)
+	require.Equal(t, now.Add(time.Hour), state.NextCheck)
+	require.Equal(t, toPTR(uint64(42)), state.Seed)
+	require.Len(t, state.Inserted, 1)
+	require.NoError(t, s.Put(state))
+	require.NoError(t, s.Put(PlaylistState{Server: "http://plex.example.com", Playlist: "Another"}))
+
+	// A check without a refill only moves the schedule along
+	later := now.Add(time.Hour)
+	state = state.WithResponse(&RandomizeResponse{SleepFor: time.Minute}, later)
+	require.Equal(t, now, state.LastRefill)
+	require.Equal(t, later.Add(time.Minute), state.NextCheck)
+
+	// Neither does a refill reason when there was nothing to top up
+	topUp := state.WithResponse(&RandomizeResponse{RefillReason: "playlist dipped below 5, was at: 4", Seed: 7, SleepFor: time.Minute}, later)
+	require.Equal(t, now, topUp.LastRefill)
+	require.Equal(t, "newly created playlist", topUp.RefillReason)
+	require.Equal(t, toPTR(uint64(42)), topUp.Seed)
+	require.Equal(t, state, state.WithResponse(&RandomizeResponse{DryRun: true, SleepFor: time.Hour}, later.Add(time.Hour)))
+	require.NoError(t, s.Put(state))
+
+	// Reopening the file sees the same states
+	got, err = NewStateStore(s.Path()).List()
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, PlaylistTitle("Another"), got[0].Playlist)
+	require.Equal(t, state, got[1])
+
+	stored, ok, err := s.Get("http://plex.example.com", "Foo")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state, stored)
+
+	_, ok, err = s.Get("http://other.example.com", "Foo")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// A refill with seed 0 can still be replayed from the stored state
+	state = state.WithResponse(&RandomizeResponse{Refilled: true, Seed: 0}, later)
+	require.NoError(t, s.Put(state))
+	stored, _, err = s.Get("http://plex.example.com", "Foo")
+	require.NoError(t, err)
+	require.Equal(t, toPTR(uint64(0)), stored.Seed)
+}
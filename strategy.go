@@ -0,0 +1,294 @@
+package goflex
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// StrategyShuffle shuffles each series and mixes them by weight.
+	StrategyShuffle = "shuffle"
+	// StrategyRoundRobin shuffles each show and takes turns between shows.
+	StrategyRoundRobin = "round-robin"
+	// StrategyNoRepeat shuffles everything, avoiding the same show twice in a row.
+	StrategyNoRepeat = "no-repeat"
+	// StrategySequential plays each show in order from its next unwatched
+	// episode, taking turns between shows in a shuffled order.
+	StrategySequential = "sequential"
+	// StrategyLeastRecent plays the episodes watched longest ago first.
+	StrategyLeastRecent = "least-recent"
+)
+
+// SeriesEpisodes are the candidate episodes of one series in a RandomizeRequest.
+type SeriesEpisodes struct {
+	// Units are episodes that must stay together, in air order.
+	Units  []EpisodeList
+	Weight float64
+	// Viewed is the series' recent history. Those episodes aren't among the
+	// units, but still tell where a show was left off.
+	Viewed EpisodeList
+}
+
+// Strategy decides the order episodes are added to a randomized playlist. It
+// orders whole units, so the playlist can be cut short without splitting one.
+type Strategy interface {
+	Order(*rand.Rand, []SeriesEpisodes) []EpisodeList
+}
+
+// StrategyFunc is a function fulfilling the Strategy interface.
+type StrategyFunc func(*rand.Rand, []SeriesEpisodes) []EpisodeList
+
+// Order fulfills the Strategy interface.
+func (f StrategyFunc) Order(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	return f(r, series)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]Strategy{
+		StrategyShuffle:     StrategyFunc(shuffleStrategy),
+		StrategyRoundRobin:  StrategyFunc(roundRobinStrategy),
+		StrategyNoRepeat:    StrategyFunc(noRepeatStrategy),
+		StrategySequential:  StrategyFunc(sequentialStrategy),
+		StrategyLeastRecent: StrategyFunc(leastRecentStrategy),
+	}
+)
+
+// RegisterStrategy makes a strategy available to RandomizeRequests by name,
+// replacing any strategy already registered with that name.
+func RegisterStrategy(name string, s Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[name] = s
+}
+
+// StrategyWithName returns the strategy registered with the given name. An
+// empty name returns the default shuffle strategy.
+func StrategyWithName(name string) (Strategy, error) {
+	if name == "" {
+		name = StrategyShuffle
+	}
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %v", name)
+	}
+	return s, nil
+}
+
+// unweightedStrategies are the built in strategies that order by show rather
+// than by series, so have no use for series weights.
+var unweightedStrategies = []string{StrategyRoundRobin, StrategyNoRepeat, StrategySequential, StrategyLeastRecent}
+
+// Strategies returns the names of all registered strategies.
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	ret := make([]string, 0, len(strategies))
+	for name := range strategies {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// shuffleStrategy shuffles the units of each series, then interleaves the
+// series in proportion to their weights.
+func shuffleStrategy(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	units := make([][]EpisodeList, len(series))
+	weights := make([]float64, len(series))
+	for idx, s := range series {
+		units[idx] = slices.Clone(s.Units)
+		shuffleUnits(r, units[idx])
+		weights[idx] = s.Weight
+	}
+	return interleaveWeighted(units, weights)
+}
+
+// roundRobinStrategy shuffles the units of each show, then takes one unit from
+// each show in turn.
+func roundRobinStrategy(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	shows := unitsByShow(series)
+	for _, units := range shows {
+		shuffleUnits(r, units)
+	}
+	shuffleUnits(r, shows)
+	return roundRobin(shows)
+}
+
+// sequentialStrategy keeps each show in air order, starting after the last
+// episode watched, taking one unit from each show in turn with the shows in a
+// shuffled order.
+func sequentialStrategy(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	viewed := EpisodeList{}
+	for _, s := range series {
+		viewed = append(viewed, s.Viewed...)
+	}
+	shows := unitsByShow(series)
+	for idx, units := range shows {
+		shows[idx] = resumeUnits(units, viewed)
+	}
+	shuffleUnits(r, shows)
+	return roundRobin(shows)
+}
+
+// resumeUnits rotates the units of a show, in air order, to start with the
+// first unit airing after the show's most recently watched episode, wrapping
+// round to the start once the rest have been played. Both the viewed history
+// and the units' own view counts are looked at.
+func resumeUnits(units []EpisodeList, viewed EpisodeList) []EpisodeList {
+	var latest *Episode
+	consider := func(e Episode) {
+		if e.Watched != nil && (latest == nil || e.Watched.After(*latest.Watched)) {
+			latest = &e
+		}
+	}
+	for _, e := range viewed {
+		if sameShow(e, units[0][0]) {
+			consider(e)
+		}
+	}
+	for _, unit := range units {
+		for _, e := range unit {
+			if e.ViewCount > 0 {
+				consider(e)
+			}
+		}
+	}
+	if latest == nil {
+		return units
+	}
+	start := slices.IndexFunc(units, func(unit EpisodeList) bool {
+		return (unit[0].Season > latest.Season) ||
+			((unit[0].Season == latest.Season) && (unit[0].Episode > latest.Episode))
+	})
+	if start <= 0 {
+		return units
+	}
+	return append(slices.Clone(units[start:]), units[:start]...)
+}
+
+// noRepeatStrategy shuffles every unit, then orders them so the same show is
+// never played back to back unless no other show is left.
+func noRepeatStrategy(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	shows := unitsByShow(series)
+	for _, units := range shows {
+		shuffleUnits(r, units)
+	}
+	shuffleUnits(r, shows)
+
+	ret := []EpisodeList{}
+	var last string
+	for {
+		// Take from the show with the most units left, so the largest show
+		// doesn't end up stuck repeating at the end.
+		pick := -1
+		for idx, units := range shows {
+			if len(units) == 0 || (len(ret) > 0 && showKey(units[0][0]) == last) {
+				continue
+			}
+			if pick == -1 || len(units) > len(shows[pick]) {
+				pick = idx
+			}
+		}
+		if pick == -1 {
+			// Only the last show is left, so repeats can't be avoided
+			for _, units := range shows {
+				ret = append(ret, units...)
+			}
+			return ret
+		}
+		ret = append(ret, shows[pick][0])
+		last = showKey(shows[pick][0][0])
+		shows[pick] = shows[pick][1:]
+	}
+}
+
+// leastRecentStrategy orders units by when they were last watched, never
+// watched units first. Units watched at the same time are shuffled.
+func leastRecentStrategy(r *rand.Rand, series []SeriesEpisodes) []EpisodeList {
+	units := []EpisodeList{}
+	for _, s := range series {
+		units = append(units, s.Units...)
+	}
+	shuffleUnits(r, units)
+	slices.SortStableFunc(units, func(a, b EpisodeList) int {
+		return lastWatched(a).Compare(lastWatched(b))
+	})
+	return units
+}
+
+// lastWatched returns the latest time any episode in the unit was watched.
+func lastWatched(unit EpisodeList) time.Time {
+	var ret time.Time
+	for _, e := range unit {
+		if e.ViewCount > 0 && e.Watched != nil && e.Watched.After(ret) {
+			ret = *e.Watched
+		}
+	}
+	return ret
+}
+
+// unitsByShow regroups the units of all series by show, keeping air order.
+// Shows are returned sorted by title, then by ID.
+func unitsByShow(series []SeriesEpisodes) [][]EpisodeList {
+	byShow := map[string][]EpisodeList{}
+	for _, s := range series {
+		for _, unit := range s.Units {
+			key := showKey(unit[0])
+			byShow[key] = append(byShow[key], unit)
+		}
+	}
+	ret := make([][]EpisodeList, 0, len(byShow))
+	for _, units := range byShow {
+		ret = append(ret, units)
+	}
+	slices.SortFunc(ret, func(a, b []EpisodeList) int {
+		return cmp.Or(
+			cmp.Compare(a[0][0].Show, b[0][0].Show),
+			cmp.Compare(a[0][0].ShowID, b[0][0].ShowID),
+		)
+	})
+	return ret
+}
+
+// showKey identifies the show of an episode, by ID when known so shows
+// sharing a title stay apart.
+func showKey(e Episode) string {
+	if e.ShowID != 0 {
+		return fmt.Sprintf("id:%v", e.ShowID)
+	}
+	return fmt.Sprintf("title:%v", e.Show)
+}
+
+// sameShow returns true if both episodes belong to the same show, comparing
+// IDs when both are known and titles otherwise.
+func sameShow(a, b Episode) bool {
+	if a.ShowID != 0 && b.ShowID != 0 {
+		return a.ShowID == b.ShowID
+	}
+	return a.Show == b.Show
+}
+
+// roundRobin takes one unit from each show in turn until all are used.
+func roundRobin(shows [][]EpisodeList) []EpisodeList {
+	ret := []EpisodeList{}
+	for i := 0; ; i++ {
+		added := false
+		for _, units := range shows {
+			if i < len(units) {
+				ret = append(ret, units[i])
+				added = true
+			}
+		}
+		if !added {
+			return ret
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package goflex
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func strategyFixture() []SeriesEpisodes {
+	units := func(show ShowTitle, n int) []EpisodeList {
+		ret := []EpisodeList{}
+		for i := 1; i <= n; i++ {
+			ret = append(ret, EpisodeList{{Show: show, Season: 1, Episode: EpisodeNumber(i)}})
+		}
+		return ret
+	}
+	return []SeriesEpisodes{
+		{Units: units("a", 4), Weight: 1},
+		{Units: append(units("b", 2), units("c", 2)...), Weight: 1},
+	}
+}
+
+func TestStrategyWithName(t *testing.T) {
+	got, err := StrategyWithName("")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	_, err = StrategyWithName("chaos")
+	require.EqualError(t, err, "unknown strategy: chaos")
+	require.Equal(t, []string{"least-recent", "no-repeat", "round-robin", "sequential", "shuffle"}, Strategies())
+
+	_, err = NewRandomizeRequest("foo", []RandomizeSeries{{Filter: EpisodeFilter{Show: "bar"}}}, WithStrategy("chaos"))
+	require.EqualError(t, err, "unknown strategy: chaos")
+}
+
+func TestStrategies(t *testing.T) {
+	for _, name := range Strategies() {
+		s, err := StrategyWithName(name)
+		require.NoError(t, err)
+		got := flattenUnits(s.Order(rand.New(rand.NewPCG(1, 2)), strategyFixture()))
+		require.Len(t, got, 8, name)
+		require.ElementsMatch(t, flattenUnits(append(strategyFixture()[0].Units, strategyFixture()[1].Units...)), got, name)
+	}
+}
+
+func TestRoundRobinStrategies(t *testing.T) {
+	for _, name := range []string{StrategyRoundRobin, StrategySequential} {
+		s, err := StrategyWithName(name)
+		require.NoError(t, err)
+		got := flattenUnits(s.Order(rand.New(rand.NewPCG(1, 2)), strategyFixture()))
+		// Every show takes a turn before any show repeats
+		require.ElementsMatch(t, []ShowTitle{"a", "b", "c"}, []ShowTitle{got[0].Show, got[1].Show, got[2].Show}, name)
+	}
+
+	// Sequential keeps each show in air order
+	s, err := StrategyWithName(StrategySequential)
+	require.NoError(t, err)
+	last := map[ShowTitle]EpisodeNumber{}
+	for _, e := range flattenUnits(s.Order(rand.New(rand.NewPCG(3, 4)), strategyFixture())) {
+		require.Greater(t, e.Episode, last[e.Show])
+		last[e.Show] = e.Episode
+	}
+}
+
+func TestSequentialStrategyResumes(t *testing.T) {
+	watched := func(days int) *time.Time {
+		return toPTR(time.Date(2024, 1, days, 0, 0, 0, 0, time.UTC))
+	}
+	episode := func(n int) EpisodeList {
+		return EpisodeList{{Show: "a", ShowID: 1, Season: 1, Episode: EpisodeNumber(n), Watched: toPTR(time.Unix(0, 0))}}
+	}
+	s, err := StrategyWithName(StrategySequential)
+	require.NoError(t, err)
+	order := func(series SeriesEpisodes) []EpisodeNumber {
+		ret := []EpisodeNumber{}
+		for _, e := range flattenUnits(s.Order(rand.New(rand.NewPCG(1, 2)), []SeriesEpisodes{series})) {
+			ret = append(ret, e.Episode)
+		}
+		return ret
+	}
+
+	// Nothing watched starts from the beginning
+	units := []EpisodeList{episode(1), episode(3), episode(4), episode(5)}
+	require.Equal(t, []EpisodeNumber{1, 3, 4, 5}, order(SeriesEpisodes{Units: units}))
+
+	// Recent history picks up after the last episode watched, wrapping round
+	require.Equal(t, []EpisodeNumber{3, 4, 5, 1}, order(SeriesEpisodes{Units: units, Viewed: EpisodeList{
+		{Show: "a", ShowID: 1, Season: 1, Episode: 2, Watched: watched(10)},
+	}}))
+
+	// So does an episode watched before the lookback
+	units[2] = EpisodeList{{Show: "a", ShowID: 1, Season: 1, Episode: 4, ViewCount: 1, Watched: watched(20)}}
+	require.Equal(t, []EpisodeNumber{5, 1, 3, 4}, order(SeriesEpisodes{Units: units, Viewed: EpisodeList{
+		{Show: "a", ShowID: 1, Season: 1, Episode: 2, Watched: watched(10)},
+	}}))
+
+	// History of another show with the same title doesn't count
+	units[2] = episode(4)
+	require.Equal(t, []EpisodeNumber{1, 3, 4, 5}, order(SeriesEpisodes{Units: units, Viewed: EpisodeList{
+		{Show: "a", ShowID: 2, Season: 1, Episode: 2, Watched: watched(10)},
+	}}))
+}
+
+func TestStrategiesSameTitledShows(t *testing.T) {
+	units := func(id, n int) []EpisodeList {
+		ret := []EpisodeList{}
+		for i := 1; i <= n; i++ {
+			ret = append(ret, EpisodeList{{Show: "The Office", ShowID: id, Season: 1, Episode: EpisodeNumber(i)}})
+		}
+		return ret
+	}
+	series := []SeriesEpisodes{{Units: units(1, 3)}, {Units: units(2, 3)}}
+	require.Len(t, unitsByShow(series), 2)
+
+	// Taking turns alternates between the two shows
+	for _, name := range []string{StrategyRoundRobin, StrategyNoRepeat, StrategySequential} {
+		s, err := StrategyWithName(name)
+		require.NoError(t, err)
+		got := flattenUnits(s.Order(rand.New(rand.NewPCG(1, 2)), series))
+		for idx := 1; idx < len(got); idx++ {
+			require.NotEqual(t, got[idx-1].ShowID, got[idx].ShowID, name)
+		}
+	}
+}
+
+func TestStrategyWeights(t *testing.T) {
+	series := []RandomizeSeries{{Filter: EpisodeFilter{Show: "bar"}, Weight: 2}}
+	_, err := NewRandomizeRequest("foo", series, WithStrategy(StrategyShuffle))
+	require.NoError(t, err)
+	_, err = NewRandomizeRequest("foo", series, WithStrategy(StrategyRoundRobin))
+	require.EqualError(t, err, "weight is only used by the shuffle strategy, not round-robin")
+	_, err = NewRandomizeRequest("foo", []RandomizeSeries{{Filter: EpisodeFilter{Show: "bar"}}}, WithStrategy(StrategyRoundRobin))
+	require.NoError(t, err)
+}
+
+func TestNoRepeatStrategy(t *testing.T) {
+	s, err := StrategyWithName(StrategyNoRepeat)
+	require.NoError(t, err)
+	for seed := range uint64(20) {
+		got := flattenUnits(s.Order(rand.New(rand.NewPCG(seed, seed)), strategyFixture()))
+		for idx := 1; idx < len(got); idx++ {
+			require.NotEqual(t, got[idx-1].Show, got[idx].Show, "seed %v: %v", seed, got)
+		}
+	}
+}
+
+func TestLeastRecentStrategy(t *testing.T) {
+	watched := func(days int) *time.Time {
+		return toPTR(time.Date(2024, 1, days, 0, 0, 0, 0, time.UTC))
+	}
+	s, err := StrategyWithName(StrategyLeastRecent)
+	require.NoError(t, err)
+	got := flattenUnits(s.Order(rand.New(rand.NewPCG(1, 2)), []SeriesEpisodes{{Units: []EpisodeList{
+		{{Title: "recent", ViewCount: 1, Watched: watched(20)}},
+		{{Title: "never", Watched: watched(30)}},
+		{{Title: "old", ViewCount: 2, Watched: watched(2)}},
+	}}}))
+	require.Equal(t, []string{"never", "old", "recent"}, []string{got[0].Title, got[1].Title, got[2].Title})
+}
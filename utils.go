@@ -3,6 +3,7 @@ package goflex
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,6 +27,23 @@ func dateFromUnixString(s string) (*time.Time, error) {
 	return toPTR(time.Unix(vInt64, 0)), nil
 }
 
+// optionalDateFromUnixString is like dateFromUnixString, but returns nil
+// when the timestamp is unset.
+func optionalDateFromUnixString(s string) (*time.Time, error) {
+	if (s == "") || (s == "0") {
+		return nil, nil
+	}
+	return dateFromUnixString(s)
+}
+
+// dateFromUnix returns nil for a zero timestamp.
+func dateFromUnix(i int64) *time.Time {
+	if i == 0 {
+		return nil
+	}
+	return toPTR(time.Unix(i, 0))
+}
+
 func toPTR[V any](v V) *V {
 	return &v
 }
@@ -43,3 +61,53 @@ const DayHours = 24
 func daysToDuration(days int) time.Duration {
 	return time.Duration(days) * time.Hour * DayHours
 }
+
+// intFromString converts an optional numeric attribute, treating empty as 0.
+func intFromString(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// floatFromString converts an optional decimal attribute, treating empty as 0.
+func floatFromString(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// tagNames flattens a list of tags down to their names.
+func tagNames(tags []Tag) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	ret := make([]string, len(tags))
+	for idx, tag := range tags {
+		ret[idx] = tag.Tag
+	}
+	return ret
+}
+
+// guidIDs flattens a list of GUIDs down to their identifiers.
+func guidIDs(guids []GUID) []string {
+	if len(guids) == 0 {
+		return nil
+	}
+	ret := make([]string, len(guids))
+	for idx, guid := range guids {
+		ret[idx] = guid.ID
+	}
+	return ret
+}
+
+// containsFold returns true if s is in the list, ignoring case.
+func containsFold(l []string, s string) bool {
+	for _, item := range l {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}